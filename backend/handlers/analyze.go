@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"etrans/middleware"
+	"etrans/models"
+	"etrans/translator"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// priceTablePath 返回模型价格表路径，可通过环境变量 PRICE_TABLE 指定
+func priceTablePath() string {
+	if path := os.Getenv("PRICE_TABLE"); path != "" {
+		return path
+	}
+	return filepath.Join("data", "prices.json")
+}
+
+// AnalyzeHandler 试运行：按与 /translate 相同的表单估算各章节的文本量、缓存命中、请求数和费用，不创建任务
+func AnalyzeHandler(c *gin.Context) {
+	sessionID := middleware.GetSessionID(c)
+	if sessionID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的会话"})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "未找到上传文件"})
+		return
+	}
+	if !strings.EqualFold(filepath.Ext(file.Filename), ".epub") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "只支持分析 .epub 文件"})
+		return
+	}
+
+	req, err := parseTranslateRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 上传文件只用于分析，保存到临时文件，结束后删除
+	tmp, err := os.CreateTemp("", "analyze-*.epub")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建临时文件失败: " + err.Error()})
+		return
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	if err := c.SaveUploadedFile(file, tmp.Name()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存文件失败: " + err.Error()})
+		return
+	}
+
+	// 使用会话的缓存统计命中，分析本身不写入缓存
+	userCacheDir := filepath.Join("data", "users", sessionID, "cache")
+	if err := os.MkdirAll(userCacheDir, 0755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建缓存目录失败: " + err.Error()})
+		return
+	}
+	cache, _ := translator.NewCache(userCacheDir)
+	defer cache.Close()
+	if req.ForceRetranslate {
+		cache.DisableCache()
+	}
+
+	docTranslator, err := newDocumentTranslator(sessionID, req, cache)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	info, err := translator.GetDocumentInfo(tmp.Name())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取文档信息失败: " + err.Error()})
+		return
+	}
+	estimate, err := docTranslator.AnalyzeEPUB(tmp.Name(), req.TargetLanguage, req.UserPrompt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prices, err := translator.LoadPriceTable(priceTablePath())
+	if err != nil {
+		log.Printf("[会话 %s] %v，本次不估算费用", sessionID[:8], err)
+		prices = translator.PriceTable{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"info":     info,
+		"estimate": estimate,
+		"cost":     estimateCost(prices, req, estimate),
+	})
+}
+
+// estimateCost 按价格表估算初译和审校的费用；价格表中找不到的模型列在 unpriced 中，不计入总价
+// 只按主提供商计价，备用提供商仅在主提供商失败时使用
+func estimateCost(prices translator.PriceTable, req models.TranslateRequest, estimate *translator.Estimate) gin.H {
+	type pass struct {
+		name   string
+		config models.LLMConfig
+		tokens *translator.PassEstimate
+	}
+	passes := []pass{{"translate", req.LLMConfig, &estimate.Translate}}
+	if estimate.Review != nil {
+		config := req.LLMConfig
+		if req.ReviewLLMConfig != nil {
+			config = *req.ReviewLLMConfig
+		}
+		passes = append(passes, pass{"review", config, estimate.Review})
+	}
+
+	result := gin.H{}
+	currency := ""
+	total := 0.0
+	mixed := false
+	unpriced := make([]string, 0)
+	for _, p := range passes {
+		label := p.config.Provider + "/" + p.config.Model
+		price, ok := prices.Lookup(p.config.Provider, p.config.Model)
+		if !ok {
+			unpriced = append(unpriced, label)
+			continue
+		}
+		cost := price.Cost(p.tokens.InputTokens, p.tokens.OutputTokens)
+		result[p.name] = gin.H{"model": label, "cost": cost, "currency": price.Currency}
+		// 币种不一致时无法相加，只给出各遍的费用
+		if currency != "" && price.Currency != currency {
+			mixed = true
+		}
+		currency = price.Currency
+		total += cost
+	}
+	if currency == "" {
+		currency = translator.DefaultCurrency
+	}
+	result["currency"] = currency
+	if !mixed {
+		result["total"] = total
+	}
+	result["priced"] = len(unpriced) == 0
+	result["unpriced"] = unpriced
+	return result
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"etrans/middleware"
+	"etrans/models"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// glossaryPayload 术语表审核接口的请求体
+type glossaryPayload struct {
+	Entries []models.GlossaryEntry `json:"entries"`
+}
+
+// GetGlossaryHandler 获取任务的建议术语表和当前使用的术语表
+func GetGlossaryHandler(c *gin.Context) {
+	sessionID := middleware.GetSessionID(c)
+	if sessionID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的会话"})
+		return
+	}
+
+	taskID := c.Param("taskId")
+
+	task, exists := taskManager.GetTask(sessionID, taskID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   task.Status,
+		"proposed": task.ProposedGlossary,
+		"glossary": task.Request.Glossary,
+	})
+}
+
+// UpdateGlossaryHandler 修改待审核的建议术语表
+func UpdateGlossaryHandler(c *gin.Context) {
+	sessionID := middleware.GetSessionID(c)
+	if sessionID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的会话"})
+		return
+	}
+
+	taskID := c.Param("taskId")
+
+	task, exists := taskManager.GetTask(sessionID, taskID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	if task.Status != "glossary_review" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "任务不在术语审核阶段"})
+		return
+	}
+
+	entries, msg := bindGlossaryEntries(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	// 状态在更新时再次检查，避免覆盖已确认的任务
+	updated := false
+	taskManager.UpdateTask(sessionID, taskID, func(t *models.TranslateTask) {
+		if t.Status != "glossary_review" {
+			return
+		}
+		t.ProposedGlossary = entries
+		updated = true
+	})
+	if !updated {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "任务不在术语审核阶段"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "术语表已更新", "proposed": entries})
+}
+
+// ConfirmGlossaryHandler 确认术语表并继续翻译
+// 请求体可带上最终的术语表；为空时使用当前的建议术语表
+func ConfirmGlossaryHandler(c *gin.Context) {
+	sessionID := middleware.GetSessionID(c)
+	if sessionID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的会话"})
+		return
+	}
+
+	taskID := c.Param("taskId")
+
+	task, exists := taskManager.GetTask(sessionID, taskID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	if task.Status != "glossary_review" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "任务不在术语审核阶段"})
+		return
+	}
+
+	var entries []models.GlossaryEntry
+	hasEntries := c.Request.ContentLength > 0
+	if hasEntries {
+		var msg string
+		if entries, msg = bindGlossaryEntries(c); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+	}
+
+	// 检查状态和切换到 pending 在同一次更新中完成，并发的确认请求只有一个能继续翻译
+	var req models.TranslateRequest
+	confirmed := false
+	taskManager.UpdateTask(sessionID, taskID, func(t *models.TranslateTask) {
+		if t.Status != "glossary_review" {
+			return
+		}
+		if !hasEntries {
+			entries = t.ProposedGlossary
+		}
+		t.Request.Glossary = entries
+		t.Request.ExtractGlossary = false // 已审核，继续时不再提取
+		t.ProposedGlossary = nil
+		t.Status = "pending"
+		req = t.Request
+		confirmed = true
+	})
+	if !confirmed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "任务不在术语审核阶段"})
+		return
+	}
+
+	// 与恢复任务相同，重新构建源文件路径
+	ext := filepath.Ext(task.SourceFile)
+	sourcePath := filepath.Join("data", "users", sessionID, "uploads", taskID+ext)
+
+	go processTranslation(sessionID, taskID, sourcePath, req)
+
+	c.JSON(http.StatusOK, gin.H{"message": "术语表已确认，继续翻译"})
+}
+
+// bindGlossaryEntries 解析并清理请求中的术语表
+func bindGlossaryEntries(c *gin.Context) ([]models.GlossaryEntry, string) {
+	var payload glossaryPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		return nil, "术语表格式错误: " + err.Error()
+	}
+
+	var entries []models.GlossaryEntry
+	for _, e := range payload.Entries {
+		e.Source = strings.TrimSpace(e.Source)
+		e.Target = strings.TrimSpace(e.Target)
+		if e.Source == "" {
+			continue
+		}
+		if e.Target == "" && !e.DoNotTranslate {
+			return nil, "术语缺少目标译法: " + e.Source
+		}
+		entries = append(entries, e)
+	}
+	return entries, ""
+}
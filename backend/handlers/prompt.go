@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"etrans/translator"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PromptPresetsHandler 返回内置提示词模板，前端可以在此基础上修改为自定义模板
+func PromptPresetsHandler(c *gin.Context) {
+	presets := make([]gin.H, 0)
+	for _, name := range translator.PromptPresetNames() {
+		text, _ := translator.PromptPresetText(name)
+		presets = append(presets, gin.H{"name": name, "template": text})
+	}
+	c.JSON(http.StatusOK, gin.H{"presets": presets})
+}
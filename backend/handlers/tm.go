@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"etrans/middleware"
+	"etrans/translator"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// translationMemoryPath 返回会话的翻译记忆库文件路径
+func translationMemoryPath(sessionID string) string {
+	return filepath.Join("data", "users", sessionID, "tm.json")
+}
+
+// matchBand 返回翻译记忆库匹配度所在的区间，与常见 CAT 工具的统计口径一致
+func matchBand(score float64) string {
+	switch {
+	case score >= 1:
+		return "100%"
+	case score >= 0.95:
+		return "95-99%"
+	case score >= 0.85:
+		return "85-94%"
+	case score >= 0.75:
+		return "75-84%"
+	default:
+		return "<75%"
+	}
+}
+
+// ImportTMXHandler 导入 TMX 文件到当前会话的翻译记忆库
+func ImportTMXHandler(c *gin.Context) {
+	sessionID := middleware.GetSessionID(c)
+	if sessionID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的会话"})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请上传TMX文件"})
+		return
+	}
+	if !strings.EqualFold(filepath.Ext(file.Filename), ".tmx") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "只支持 .tmx 文件"})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取TMX文件失败"})
+		return
+	}
+	defer src.Close()
+
+	memory, err := translator.OpenTranslationMemory(translationMemoryPath(sessionID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	count, err := memory.ImportTMX(src)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := memory.Save(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存翻译记忆库失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  fmt.Sprintf("已导入 %d 个翻译单元", count),
+		"imported": count,
+		"total":    memory.Len(),
+	})
+}
+
+// ExportTMXHandler 导出当前会话的翻译记忆库为 TMX
+// 可用 source、target 查询参数限定语言对
+func ExportTMXHandler(c *gin.Context) {
+	sessionID := middleware.GetSessionID(c)
+	if sessionID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的会话"})
+		return
+	}
+
+	memory, err := translator.OpenTranslationMemory(translationMemoryPath(sessionID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "application/x-tmx+xml; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="etrans.tmx"`)
+	if err := memory.ExportTMX(c.Writer, c.Query("source"), c.Query("target")); err != nil {
+		c.Status(http.StatusInternalServerError)
+	}
+}
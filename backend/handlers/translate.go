@@ -2,14 +2,17 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"etrans/middleware"
 	"etrans/models"
 	"etrans/translator"
 	"fmt"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -130,65 +133,9 @@ func TranslateHandler(c *gin.Context) {
 	}
 
 	// 解析配置
-	var req models.TranslateRequest
-	req.TargetLanguage = c.PostForm("targetLanguage")
-	req.UserPrompt = c.PostForm("userPrompt")
-	req.ForceRetranslate = c.PostForm("forceRetranslate") == "true"
-	req.GenerateMode = c.PostForm("generateMode") // 新增：生成模式
-
-	// 解析 LLM 配置
-	llmConfigStr := c.PostForm("llmConfig")
-	if llmConfigStr != "" {
-		if err := json.Unmarshal([]byte(llmConfigStr), &req.LLMConfig); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "LLM 配置格式错误: " + err.Error()})
-			return
-		}
-	}
-
-	// 验证必填字段
-	if req.TargetLanguage == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "目标语言不能为空"})
-		return
-	}
-
-	// 设置默认生成模式
-	if req.GenerateMode == "" {
-		req.GenerateMode = "bilingual" // 默认双语
-	}
-	if req.LLMConfig.Provider == "" {
-		req.LLMConfig.Provider = "openai" // 默认使用 OpenAI
-	}
-	if req.LLMConfig.APIURL == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "API URL 不能为空"})
-		return
-	}
-	// 如果 Model 为空，尝试从 URL 中提取或使用默认值
-	if req.LLMConfig.Model == "" {
-		// 为不同提供商设置默认模型
-		switch req.LLMConfig.Provider {
-		case "openai":
-			req.LLMConfig.Model = "gpt-3.5-turbo"
-		case "claude":
-			req.LLMConfig.Model = "claude-3-5-sonnet-20241022"
-		case "gemini":
-			req.LLMConfig.Model = "gemini-pro"
-		case "deepseek":
-			req.LLMConfig.Model = "deepseek-chat"
-		case "ollama":
-			req.LLMConfig.Model = "llama2"
-		case "custom":
-			// 自定义提供商允许空模型（某些 API 可能不需要）
-			req.LLMConfig.Model = "default"
-		default:
-			req.LLMConfig.Model = "gpt-3.5-turbo"
-		}
-	}
-	// 本地模型（Ollama、NLTranslator 等）不需要 API Key
-	needsAPIKey := req.LLMConfig.Provider != "ollama" &&
-		req.LLMConfig.Provider != "nltranslator"
-
-	if needsAPIKey && req.LLMConfig.APIKey == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "API Key 不能为空"})
+	req, err := parseTranslateRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -240,6 +187,259 @@ func TranslateHandler(c *gin.Context) {
 	})
 }
 
+// parseTranslateRequest 解析并校验翻译请求的表单字段（上传文件以外的部分）
+func parseTranslateRequest(c *gin.Context) (models.TranslateRequest, error) {
+	var req models.TranslateRequest
+	req.TargetLanguage = c.PostForm("targetLanguage")
+	req.UserPrompt = c.PostForm("userPrompt")
+	req.ForceRetranslate = c.PostForm("forceRetranslate") == "true"
+	req.GenerateMode = c.PostForm("generateMode") // 新增：生成模式
+	req.Review = c.PostForm("review") == "true"
+	req.BatchSize, _ = strconv.Atoi(c.PostForm("batchSize"))
+	req.BatchTokens, _ = strconv.Atoi(c.PostForm("batchTokens"))
+	req.Concurrency, _ = strconv.Atoi(c.PostForm("concurrency"))
+	req.ContextParagraphs, _ = strconv.Atoi(c.PostForm("contextParagraphs"))
+	req.ContextTranslations = c.PostForm("contextTranslations") == "true"
+	req.ExtractGlossary = c.PostForm("extractGlossary") == "true"
+	if threshold := c.PostForm("fuzzyThreshold"); threshold != "" {
+		value, err := strconv.ParseFloat(threshold, 64)
+		if err != nil || value < 0 || value > 1 {
+			return req, errors.New("模糊匹配阈值必须是 0 到 1 之间的数")
+		}
+		req.FuzzyThreshold = value
+	}
+
+	// 提示词模板在提交时校验，避免任务开始后才发现模板错误
+	req.PromptPreset = c.PostForm("promptPreset")
+	req.PromptTemplate = c.PostForm("promptTemplate")
+	if _, err := translator.ResolvePromptTemplate(req.PromptPreset, req.PromptTemplate); err != nil {
+		return req, err
+	}
+
+	// 解析术语表（可选，CSV/TSV）
+	if glossaryFile, err := c.FormFile("glossary"); err == nil {
+		entries, err := parseGlossaryUpload(glossaryFile)
+		if err != nil {
+			return req, err
+		}
+		req.Glossary = entries
+	}
+
+	// 解析 LLM 配置
+	llmConfigStr := c.PostForm("llmConfig")
+	if llmConfigStr != "" {
+		if err := json.Unmarshal([]byte(llmConfigStr), &req.LLMConfig); err != nil {
+			return req, fmt.Errorf("LLM 配置格式错误: %w", err)
+		}
+	}
+
+	// 审校模型（可选），为空时与初译使用同一配置
+	if reviewConfigStr := c.PostForm("reviewLlmConfig"); req.Review && reviewConfigStr != "" {
+		req.ReviewLLMConfig = &models.LLMConfig{}
+		if err := json.Unmarshal([]byte(reviewConfigStr), req.ReviewLLMConfig); err != nil {
+			return req, fmt.Errorf("审校 LLM 配置格式错误: %w", err)
+		}
+	}
+
+	// 验证必填字段
+	if req.TargetLanguage == "" {
+		return req, errors.New("目标语言不能为空")
+	}
+
+	// 设置默认生成模式
+	if req.GenerateMode == "" {
+		req.GenerateMode = "bilingual" // 默认双语
+	}
+	if msg := normalizeLLMConfig(&req.LLMConfig); msg != "" {
+		return req, errors.New(msg)
+	}
+	for i := range req.LLMConfig.Fallbacks {
+		if msg := normalizeLLMConfig(&req.LLMConfig.Fallbacks[i]); msg != "" {
+			return req, fmt.Errorf("备用提供商 %d: %s", i+1, msg)
+		}
+	}
+	if req.ReviewLLMConfig != nil {
+		if msg := normalizeLLMConfig(req.ReviewLLMConfig); msg != "" {
+			return req, fmt.Errorf("审校模型: %s", msg)
+		}
+		for i := range req.ReviewLLMConfig.Fallbacks {
+			if msg := normalizeLLMConfig(&req.ReviewLLMConfig.Fallbacks[i]); msg != "" {
+				return req, fmt.Errorf("审校模型备用提供商 %d: %s", i+1, msg)
+			}
+		}
+	}
+
+	return req, nil
+}
+
+// normalizeLLMConfig 填充 LLM 配置的默认值并校验，返回错误提示（为空表示通过）
+func normalizeLLMConfig(cfg *models.LLMConfig) string {
+	if cfg.Provider == "" {
+		cfg.Provider = "openai" // 默认使用 OpenAI
+	}
+	if cfg.APIURL == "" {
+		return "API URL 不能为空"
+	}
+	// 如果 Model 为空，尝试从 URL 中提取或使用默认值
+	if cfg.Model == "" {
+		// 为不同提供商设置默认模型
+		switch cfg.Provider {
+		case "openai":
+			cfg.Model = "gpt-3.5-turbo"
+		case "claude":
+			cfg.Model = "claude-3-5-sonnet-20241022"
+		case "gemini":
+			cfg.Model = "gemini-pro"
+		case "deepseek":
+			cfg.Model = "deepseek-chat"
+		case "ollama":
+			cfg.Model = "llama2"
+		case "custom":
+			// 自定义提供商允许空模型（某些 API 可能不需要）
+			cfg.Model = "default"
+		default:
+			cfg.Model = "gpt-3.5-turbo"
+		}
+	}
+	// 未指定限流时使用服务端为该提供商配置的默认值
+	if cfg.RPM <= 0 || cfg.TPM <= 0 {
+		rpm, tpm := rateLimitFromEnv(cfg.Provider)
+		if cfg.RPM <= 0 {
+			cfg.RPM = rpm
+		}
+		if cfg.TPM <= 0 {
+			cfg.TPM = tpm
+		}
+	}
+	// 本地模型（Ollama、NLTranslator 等）不需要 API Key
+	needsAPIKey := cfg.Provider != "ollama" &&
+		cfg.Provider != "nltranslator"
+
+	if needsAPIKey && cfg.APIKey == "" {
+		return "API Key 不能为空"
+	}
+	return ""
+}
+
+// toProviderConfig 把请求中的 LLM 配置转换为翻译器的提供商配置
+func toProviderConfig(cfg models.LLMConfig) translator.ProviderConfig {
+	config := translator.ProviderConfig{
+		Type:        translator.ProviderType(cfg.Provider),
+		APIKey:      cfg.APIKey,
+		APIURL:      cfg.APIURL,
+		Model:       cfg.Model,
+		Temperature: cfg.Temperature,
+		MaxTokens:   cfg.MaxTokens,
+		Timeout:     cfg.Timeout,
+		RateLimit: translator.RateLimit{
+			RequestsPerMinute: cfg.RPM,
+			TokensPerMinute:   cfg.TPM,
+		},
+		Extra: cfg.Extra,
+	}
+	for _, fallback := range cfg.Fallbacks {
+		config.Fallbacks = append(config.Fallbacks, toProviderConfig(fallback))
+	}
+	return config
+}
+
+// maxReportedViolations 任务状态中最多保留的术语违规和质量问题条数
+const maxReportedViolations = 200
+
+// parseGlossaryUpload 解析上传的术语表文件
+func parseGlossaryUpload(fileHeader *multipart.FileHeader) ([]models.GlossaryEntry, error) {
+	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
+	if ext != ".csv" && ext != ".tsv" && ext != ".tab" && ext != ".txt" {
+		return nil, fmt.Errorf("术语表只支持 .csv 和 .tsv 文件")
+	}
+
+	f, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("读取术语表失败: %w", err)
+	}
+	defer f.Close()
+
+	glossary, err := translator.ParseGlossary(f, fileHeader.Filename)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.GlossaryEntry, len(glossary.Entries))
+	for i, e := range glossary.Entries {
+		entries[i] = models.GlossaryEntry(e)
+	}
+	return entries, nil
+}
+
+// toGlossary 把请求中的术语表转换为翻译器的术语表，为空时返回 nil
+func toGlossary(entries []models.GlossaryEntry) *translator.Glossary {
+	if len(entries) == 0 {
+		return nil
+	}
+	glossary := &translator.Glossary{Entries: make([]translator.GlossaryEntry, len(entries))}
+	for i, e := range entries {
+		glossary.Entries[i] = translator.GlossaryEntry(e)
+	}
+	return glossary
+}
+
+// rateLimitFromEnv 读取提供商的默认限流配置，如 OPENAI_RPM、OPENAI_TPM
+func rateLimitFromEnv(provider string) (rpm, tpm int) {
+	prefix := strings.ToUpper(provider)
+	rpm, _ = strconv.Atoi(os.Getenv(prefix + "_RPM"))
+	tpm, _ = strconv.Atoi(os.Getenv(prefix + "_TPM"))
+	return rpm, tpm
+}
+
+// newDocumentTranslator 按请求配置创建文档翻译器：提供商（链）、会话的翻译记忆库、审校、提示词模板和翻译选项
+func newDocumentTranslator(sessionID string, req models.TranslateRequest, cache *translator.Cache) (*translator.DocumentTranslator, error) {
+	providerConfig := toProviderConfig(req.LLMConfig)
+
+	docTranslator, err := translator.NewDocumentTranslator(providerConfig, cache)
+	if err != nil {
+		return nil, fmt.Errorf("创建翻译客户端失败: %w", err)
+	}
+
+	// 翻译记忆库按会话保存，导入的 TMX 和之前任务的译文都会作为精确匹配
+	memory, err := translator.OpenTranslationMemory(translationMemoryPath(sessionID))
+	if err != nil {
+		log.Printf("[会话 %s] 打开翻译记忆库失败，本次不使用: %v", sessionID[:8], err)
+	} else {
+		docTranslator.Memory = memory
+	}
+
+	// 两遍翻译：每段初译后再审校，审校模型未配置时使用初译的配置
+	if req.Review {
+		reviewConfig := providerConfig
+		if req.ReviewLLMConfig != nil {
+			reviewConfig = toProviderConfig(*req.ReviewLLMConfig)
+		}
+		if err := docTranslator.EnableReview(reviewConfig); err != nil {
+			return nil, fmt.Errorf("创建审校客户端失败: %w", err)
+		}
+	}
+
+	// 模板已在提交时校验，出错时退回不使用模板
+	promptTemplate, err := translator.ResolvePromptTemplate(req.PromptPreset, req.PromptTemplate)
+	if err != nil {
+		log.Printf("[会话 %s] 提示词模板无效，本次不使用: %v", sessionID[:8], err)
+	}
+
+	docTranslator.Options = translator.TranslateOptions{
+		BatchSize:           req.BatchSize,
+		BatchTokens:         req.BatchTokens,
+		Concurrency:         req.Concurrency,
+		ContextParagraphs:   req.ContextParagraphs,
+		ContextTranslations: req.ContextTranslations,
+		Glossary:            toGlossary(req.Glossary),
+		ExtractGlossary:     req.ExtractGlossary,
+		FuzzyThreshold:      req.FuzzyThreshold,
+		MaxBlockTokens:      translator.BlockTokenLimit(providerConfig),
+		Template:            promptTemplate,
+	}
+	return docTranslator, nil
+}
+
 // processTranslation 处理翻译任务
 func processTranslation(sessionID, taskID, sourcePath string, req models.TranslateRequest) {
 	taskManager.UpdateTask(sessionID, taskID, func(t *models.TranslateTask) {
@@ -285,27 +485,45 @@ func processTranslation(sessionID, taskID, sourcePath string, req models.Transla
 		cache.DisableCache()
 	}
 
-	providerConfig := translator.ProviderConfig{
-		Type:        translator.ProviderType(req.LLMConfig.Provider),
-		APIKey:      req.LLMConfig.APIKey,
-		APIURL:      req.LLMConfig.APIURL,
-		Model:       req.LLMConfig.Model,
-		Temperature: req.LLMConfig.Temperature,
-		MaxTokens:   req.LLMConfig.MaxTokens,
-		Extra:       req.LLMConfig.Extra,
-	}
-
 	// 创建统一文档翻译器
-	docTranslator, err := translator.NewDocumentTranslator(providerConfig, cache)
+	docTranslator, err := newDocumentTranslator(sessionID, req, cache)
 	if err != nil {
 		taskManager.UpdateTask(sessionID, taskID, func(t *models.TranslateTask) {
 			t.Status = "failed"
-			t.Error = "创建翻译客户端失败: " + err.Error()
+			t.Error = err.Error()
 		})
-		log.Printf("[会话 %s][任务 %s] 创建客户端失败: %v", sessionID[:8], taskID, err)
+		log.Printf("[会话 %s][任务 %s] %v", sessionID[:8], taskID, err)
 		return
 	}
 
+	// 重试回调：记录到任务状态，便于区分被限流的任务和卡住的任务
+	docTranslator.Hooks.OnRetry = func(e translator.RetryEvent) {
+		nextRetryAt := time.Now().Add(e.Delay)
+		taskManager.UpdateTask(sessionID, taskID, func(t *models.TranslateTask) {
+			t.Retries++
+			t.LastRetryError = e.Err.Error()
+			t.NextRetryAt = &nextRetryAt
+		})
+	}
+
+	// 限流排队回调：展示当前排队等待时间
+	docTranslator.Hooks.OnQueueWait = func(wait time.Duration) {
+		taskManager.UpdateTask(sessionID, taskID, func(t *models.TranslateTask) {
+			t.QueueWait = wait.Seconds()
+		})
+	}
+
+	// 术语提取回调：保存建议术语表，等待用户审核
+	docTranslator.Hooks.OnGlossaryProposal = func(g *translator.Glossary) {
+		entries := make([]models.GlossaryEntry, len(g.Entries))
+		for i, e := range g.Entries {
+			entries[i] = models.GlossaryEntry(e)
+		}
+		taskManager.UpdateTask(sessionID, taskID, func(t *models.TranslateTask) {
+			t.ProposedGlossary = entries
+		})
+	}
+
 	// 确定输出路径
 	userOutputDir := filepath.Join("data", "users", sessionID, "outputs")
 	if err := os.MkdirAll(userOutputDir, 0755); err != nil {
@@ -351,6 +569,13 @@ func processTranslation(sessionID, taskID, sourcePath string, req models.Transla
 			log.Printf("[会话 %s][任务 %s] 任务已暂停", sessionID[:8], taskID)
 			return // 任务暂停，直接返回，不更新为 failed
 		}
+		if err == translator.ErrGlossaryReview {
+			taskManager.UpdateTask(sessionID, taskID, func(t *models.TranslateTask) {
+				t.Status = "glossary_review"
+			})
+			log.Printf("[会话 %s][任务 %s] 术语提取完成，等待审核", sessionID[:8], taskID)
+			return
+		}
 
 		errorMsg := err.Error()
 
@@ -367,9 +592,75 @@ func processTranslation(sessionID, taskID, sourcePath string, req models.Transla
 		return
 	}
 
+	// 统计各提供商翻译的文本块数，详细记录保存在缓存目录的 providers_<任务ID>.json 中
+	providerUsage := make(map[string]int)
+	for _, provider := range docTranslator.SegmentProviders() {
+		if provider != "" {
+			providerUsage[provider]++
+		}
+	}
+
+	// 统计审校的文本块数，两遍翻译的初译和审校译文保存在缓存目录的 drafts_<任务ID>.json 中
+	var reviewUsage map[string]int
+	reviewChanged := 0
+	if drafts := docTranslator.Drafts(); len(drafts) > 0 {
+		reviewUsage = make(map[string]int)
+		for _, draft := range drafts {
+			reviewUsage[draft.Reviewer]++
+			if draft.Reviewed != draft.Draft {
+				reviewChanged++
+			}
+		}
+	}
+
+	// 按检测到的语言统计文本块数，无法判断语言的不计入
+	languages := make(map[string]int)
+	for _, lang := range docTranslator.BlockLanguages() {
+		if lang != "" {
+			languages[lang]++
+		}
+	}
+
+	// 按匹配度区间统计翻译记忆库命中的文本块数，每个文本块的匹配度保存在缓存目录的 matches_<任务ID>.json 中
+	tmMatches := make(map[string]int)
+	for _, score := range docTranslator.SegmentMatches() {
+		tmMatches[matchBand(score)]++
+	}
+
+	// 术语表检查结果
+	var glossaryViolations []models.GlossaryViolation
+	for _, v := range docTranslator.GlossaryViolations() {
+		if len(glossaryViolations) >= maxReportedViolations {
+			break
+		}
+		glossaryViolations = append(glossaryViolations, models.GlossaryViolation(v))
+	}
+
+	// 质量检查结果
+	var qualityFlags []models.QualityFlag
+	for _, f := range docTranslator.QualityFlags() {
+		if len(qualityFlags) >= maxReportedViolations {
+			break
+		}
+		flag := models.QualityFlag{Source: f.Source, Translation: f.Translation}
+		for _, issue := range f.Issues {
+			flag.Issues = append(flag.Issues, string(issue))
+		}
+		qualityFlags = append(qualityFlags, flag)
+	}
+
 	// 翻译完成
 	taskManager.UpdateTask(sessionID, taskID, func(t *models.TranslateTask) {
 		t.Status = "completed"
+		t.ProviderUsage = providerUsage
+		t.ReviewUsage = reviewUsage
+		t.ReviewChanged = reviewChanged
+		t.TMMatches = tmMatches
+		t.SourceLanguage = docTranslator.SourceLanguage()
+		t.Languages = languages
+		t.SkippedBlocks = docTranslator.SkippedBlocks()
+		t.GlossaryViolations = glossaryViolations
+		t.QualityFlags = qualityFlags
 		t.Progress = 1.0
 		t.CompletedAt = time.Now()
 		t.OutputPath = actualOutputPath // 使用实际的输出路径
@@ -35,6 +35,13 @@ func main() {
 		api.GET("/tasks", handlers.GetTasksHandler)
 		api.POST("/pause/:taskId", handlers.PauseTaskHandler)
 		api.POST("/resume/:taskId", handlers.ResumeTaskHandler)
+		api.GET("/glossary/:taskId", handlers.GetGlossaryHandler)
+		api.PUT("/glossary/:taskId", handlers.UpdateGlossaryHandler)
+		api.POST("/glossary/:taskId/confirm", handlers.ConfirmGlossaryHandler)
+		api.POST("/tm/import", handlers.ImportTMXHandler)
+		api.GET("/tm/export", handlers.ExportTMXHandler)
+		api.GET("/prompt-presets", handlers.PromptPresetsHandler)
+		api.POST("/analyze", handlers.AnalyzeHandler)
 		api.GET("/sessions", handlers.GetSessionsHandler)
 		api.POST("/sessions/switch", handlers.SwitchSessionHandler)
 	}
@@ -7,13 +7,30 @@ type TranslateTask struct {
 	SessionID      string           `json:"-"` // 不返回给前端
 	SourceFile     string           `json:"sourceFile"`
 	TargetLanguage string           `json:"targetLanguage"`
-	Status         string           `json:"status"` // pending, processing, completed, failed
+	Status         string           `json:"status"` // pending, processing, glossary_review, paused, completed, failed
 	Progress       float64          `json:"progress"`
 	Error          string           `json:"error,omitempty"`
 	CreatedAt      time.Time        `json:"createdAt"`
 	CompletedAt    time.Time        `json:"completedAt,omitempty"`
 	OutputPath     string           `json:"outputPath,omitempty"`
-	Request        TranslateRequest `json:"request"` // 保存原始请求配置，用于恢复任务
+	Request        TranslateRequest `json:"request"`                  // 保存原始请求配置，用于恢复任务
+	Retries        int              `json:"retries,omitempty"`        // 累计重试次数
+	LastRetryError string           `json:"lastRetryError,omitempty"` // 最近一次重试的原因
+	NextRetryAt    *time.Time       `json:"nextRetryAt,omitempty"`    // 最近一次重试计划发起的时间
+	QueueWait      float64          `json:"queueWait,omitempty"`      // 当前因限流排队需要等待的秒数
+	ProviderUsage  map[string]int   `json:"providerUsage,omitempty"`  // 各提供商翻译的文本块数
+	ReviewUsage    map[string]int   `json:"reviewUsage,omitempty"`    // 各提供商审校的文本块数
+	ReviewChanged  int              `json:"reviewChanged,omitempty"`  // 审校修改了初译的文本块数
+	TMMatches      map[string]int   `json:"tmMatches,omitempty"`      // 翻译记忆库各匹配度区间的文本块数
+	SourceLanguage string           `json:"sourceLanguage,omitempty"` // 检测到的源语言（检测不出时为 EPUB 元数据中的语言）
+	Languages      map[string]int   `json:"languages,omitempty"`      // 检测到的各语言的文本块数
+	SkippedBlocks  int              `json:"skippedBlocks,omitempty"`  // 已是目标语言、保留原文的文本块数
+	// 未按术语表翻译的文本块（最多保留前若干条）
+	GlossaryViolations []GlossaryViolation `json:"glossaryViolations,omitempty"`
+	// 重试后仍未通过质量检查的译文（最多保留前若干条）
+	QualityFlags []QualityFlag `json:"qualityFlags,omitempty"`
+	// 术语提取生成的建议术语表，状态为 glossary_review 时等待用户审核
+	ProposedGlossary []GlossaryEntry `json:"proposedGlossary,omitempty"`
 }
 
 type LLMConfig struct {
@@ -21,15 +38,54 @@ type LLMConfig struct {
 	APIKey      string                 `json:"apiKey"`
 	APIURL      string                 `json:"apiUrl"`
 	Model       string                 `json:"model"`
-	Temperature float64                `json:"temperature"`
+	Temperature *float64               `json:"temperature,omitempty"` // 为空时使用默认值，0 表示确定性输出
 	MaxTokens   int                    `json:"maxTokens"`
-	Extra       map[string]interface{} `json:"extra,omitempty"` // 额外参数，用于自定义提供商
+	Timeout     int                    `json:"timeout,omitempty"`   // 请求超时（秒），为空时使用提供商默认值
+	RPM         int                    `json:"rpm,omitempty"`       // 每分钟请求数上限，为空时读取环境变量
+	TPM         int                    `json:"tpm,omitempty"`       // 每分钟 token 数上限，为空时读取环境变量
+	Extra       map[string]interface{} `json:"extra,omitempty"`     // 额外参数，如 top_p、seed、response_format 等，会透传到请求体
+	Fallbacks   []LLMConfig            `json:"fallbacks,omitempty"` // 备用提供商，按顺序在失败、拒绝或输出不合格时使用
 }
 
 type TranslateRequest struct {
-	TargetLanguage   string    `json:"targetLanguage"`
-	LLMConfig        LLMConfig `json:"llmConfig"`
-	UserPrompt       string    `json:"userPrompt,omitempty"`
-	ForceRetranslate bool      `json:"forceRetranslate,omitempty"` // 是否强制重新翻译（忽略缓存）
-	GenerateMode     string    `json:"generateMode,omitempty"`     // 生成模式：bilingual（双语）或 monolingual（单语）
+	TargetLanguage      string          `json:"targetLanguage"`
+	LLMConfig           LLMConfig       `json:"llmConfig"`
+	UserPrompt          string          `json:"userPrompt,omitempty"`
+	ForceRetranslate    bool            `json:"forceRetranslate,omitempty"`    // 是否强制重新翻译（忽略缓存）
+	GenerateMode        string          `json:"generateMode,omitempty"`        // 生成模式：bilingual（双语）或 monolingual（单语）
+	Review              bool            `json:"review,omitempty"`              // 初译后再审校一遍（两遍翻译）
+	ReviewLLMConfig     *LLMConfig      `json:"reviewLlmConfig,omitempty"`     // 审校使用的模型，为空时与初译相同
+	BatchSize           int             `json:"batchSize,omitempty"`           // 批量模式下每次请求的文本块数，<= 1 表示逐条翻译
+	BatchTokens         int             `json:"batchTokens,omitempty"`         // 批量模式下每次请求的原文 token 预算
+	Concurrency         int             `json:"concurrency,omitempty"`         // 同时进行的翻译请求数，<= 1 表示顺序翻译
+	ContextParagraphs   int             `json:"contextParagraphs,omitempty"`   // 作为上下文附带的前文段落数
+	ContextTranslations bool            `json:"contextTranslations,omitempty"` // 上下文是否附带前文译文（启用后按顺序翻译）
+	Glossary            []GlossaryEntry `json:"glossary,omitempty"`            // 术语表，随 EPUB 以 CSV/TSV 上传
+	ExtractGlossary     bool            `json:"extractGlossary,omitempty"`     // 翻译前先提取术语，生成建议术语表供审核
+	FuzzyThreshold      float64         `json:"fuzzyThreshold,omitempty"`      // 翻译记忆库模糊匹配阈值（0~1），0 表示不使用
+	PromptPreset        string          `json:"promptPreset,omitempty"`        // 内置提示词模板名称
+	PromptTemplate      string          `json:"promptTemplate,omitempty"`      // 自定义提示词模板（text/template），优先于内置模板
+}
+
+// GlossaryEntry 术语表条目
+type GlossaryEntry struct {
+	Source         string `json:"source"`
+	Target         string `json:"target"`
+	CaseSensitive  bool   `json:"caseSensitive,omitempty"`  // 匹配原文时区分大小写
+	DoNotTranslate bool   `json:"doNotTranslate,omitempty"` // 保留原文不翻译
+}
+
+// GlossaryViolation 译文未按术语表翻译的记录
+type GlossaryViolation struct {
+	Term        string `json:"term"`
+	Expected    string `json:"expected"`
+	Source      string `json:"source"`
+	Translation string `json:"translation"`
+}
+
+// QualityFlag 未通过质量检查、按原样保留的译文
+type QualityFlag struct {
+	Source      string   `json:"source"`
+	Translation string   `json:"translation"`
+	Issues      []string `json:"issues"` // unchanged, wrong_script, length_ratio
 }
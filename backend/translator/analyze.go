@@ -0,0 +1,177 @@
+package translator
+
+import (
+	"fmt"
+	"log"
+	"unicode/utf8"
+)
+
+// ChapterEstimate 单个章节（HTML 文件）的文本统计
+type ChapterEstimate struct {
+	File       string `json:"file"`
+	Title      string `json:"title"`
+	Blocks     int    `json:"blocks"`
+	Characters int    `json:"characters"`
+	Tokens     int    `json:"tokens"`
+}
+
+// PassEstimate 一遍翻译（初译或审校）的请求数和 token 数估算
+type PassEstimate struct {
+	Requests     int `json:"requests"`
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+}
+
+// Estimate 翻译前的工作量估算，不发出任何翻译请求
+type Estimate struct {
+	Chapters   []ChapterEstimate `json:"chapters"`
+	Blocks     int               `json:"blocks"`
+	Characters int               `json:"characters"`
+	Tokens     int               `json:"tokens"`
+	// Units 按原文和提示词去重后的翻译单元数（过长的文本块已拆分）
+	Units int `json:"units"`
+	// CachedUnits 缓存命中，MemoryUnits 翻译记忆库精确匹配，SkippedUnits 已是目标语言，这些单元不会发出请求
+	CachedUnits  int `json:"cachedUnits"`
+	MemoryUnits  int `json:"memoryUnits"`
+	SkippedUnits int `json:"skippedUnits"`
+	// PendingUnits、PendingTokens 需要翻译的单元数及其原文 token 数
+	PendingUnits  int `json:"pendingUnits"`
+	PendingTokens int `json:"pendingTokens"`
+	// SourceLanguage 检测到的源语言
+	SourceLanguage string        `json:"sourceLanguage,omitempty"`
+	Translate      PassEstimate  `json:"translate"`
+	Review         *PassEstimate `json:"review,omitempty"`
+}
+
+// AnalyzeEPUB 估算翻译 EPUB 的工作量：各章节的文本块数、字符数和 token 数，
+// 会被跳过的单元（缓存、翻译记忆库、已是目标语言），以及按当前选项（批量、上下文、审校）需要的请求数和 token 数
+// 查找顺序与 TranslateEPUB 一致；上下文附带前文译文时无法预知提示词，这些单元按需要翻译计算
+// 译文 token 数按原文 token 数估算，质量检查触发的重试不计入
+func (dt *DocumentTranslator) AnalyzeEPUB(inputPath, targetLanguage, userPrompt string) (*Estimate, error) {
+	epub, err := OpenEPUB(inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := epub.GetSegments()
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("EPUB中没有可翻译的文本内容")
+	}
+
+	est := &Estimate{}
+	for _, seg := range segments {
+		if n := len(est.Chapters); n == 0 || est.Chapters[n-1].File != seg.File {
+			est.Chapters = append(est.Chapters, ChapterEstimate{File: seg.File, Title: seg.Chapter})
+		}
+		chapter := &est.Chapters[len(est.Chapters)-1]
+		chapter.Blocks++
+		// 字符数和 token 数都按去掉占位符的原文计算，占位符计入请求估算
+		text := stripPlaceholders(seg.Text)
+		chapter.Characters += utf8.RuneCountInString(text)
+		chapter.Tokens += EstimateTokens(text)
+	}
+	for _, chapter := range est.Chapters {
+		est.Blocks += chapter.Blocks
+		est.Characters += chapter.Characters
+		est.Tokens += chapter.Tokens
+	}
+
+	units, _ := dt.prepareUnits(epub, segments, targetLanguage)
+	est.SourceLanguage = dt.sourceLanguage
+
+	dt.fuzzyMatches = make(map[string]TMMatch)
+	// 已是目标语言和翻译记忆库精确匹配的单元按原文计一次；其余单元与 TranslateEPUB 相同，
+	// 原文和提示词都相同的只计一次，提示词未知（等待前文译文）的各计一次
+	var pending []int
+	counted := make(map[string]bool) // 已计数的原文
+	queued := make(map[string]bool)  // 已计数的缓存键
+	for i, unit := range units {
+		if unit == "" {
+			continue
+		}
+		if inLanguage(dt.blockLanguages[unit], targetLanguage) {
+			if !counted[unit] {
+				counted[unit] = true
+				est.Units++
+				est.SkippedUnits++
+			}
+			continue
+		}
+		match, direct, ok := dt.lookupMemory(unit, dt.sourceLanguage, targetLanguage)
+		if direct {
+			if !counted[unit] {
+				counted[unit] = true
+				est.Units++
+				est.MemoryUnits++
+			}
+			continue
+		}
+		var reference *TMMatch
+		if ok {
+			reference = &match
+		}
+		if dt.contextComplete(units, i, nil) {
+			key, _, cached := dt.lookupCache(units, i, targetLanguage, userPrompt, nil, reference)
+			if queued[key] {
+				continue
+			}
+			queued[key] = true
+			if cached {
+				est.Units++
+				est.CachedUnits++
+				continue
+			}
+		} else if reference != nil {
+			dt.fuzzyMatches[unit] = *reference
+		}
+		pending = append(pending, i)
+		est.Units++
+		est.PendingUnits++
+		est.PendingTokens += EstimateTokens(stripPlaceholders(unit))
+	}
+
+	// 与 TranslateEPUB 相同的分组方式，每组一次请求
+	pendingTexts := make([]string, len(pending))
+	for i, idx := range pending {
+		pendingTexts[i] = units[idx]
+	}
+	maxSegments, maxTokens := dt.Options.batchLimits()
+	for _, group := range packBatches(pendingTexts, maxSegments, maxTokens) {
+		positions := make([]int, len(group))
+		for i, idx := range group {
+			positions[i] = pending[idx]
+		}
+		prompt := dt.segmentPrompt(units, positions, targetLanguage, userPrompt, nil)
+
+		est.Translate.Requests++
+		if len(group) == 1 {
+			est.Translate.InputTokens += EstimateTokens(buildSystemPrompt(targetLanguage, prompt)) + EstimateTokens(units[positions[0]])
+			est.Translate.OutputTokens += EstimateTokens(units[positions[0]])
+			continue
+		}
+		texts := make([]string, len(group))
+		for i, pos := range positions {
+			texts[i] = units[pos]
+		}
+		payload, err := batchPayload(texts)
+		if err != nil {
+			log.Printf("估算批量请求失败: %v", err)
+			continue
+		}
+		est.Translate.InputTokens += EstimateTokens(buildSystemPrompt(targetLanguage, batchPrompt(prompt))) + EstimateTokens(payload)
+		est.Translate.OutputTokens += EstimateTokens(payload)
+	}
+
+	// 审校逐段进行，提示词中附带初译（按原文 token 数估算）
+	if dt.Reviewer != nil {
+		est.Review = &PassEstimate{}
+		for _, pos := range pending {
+			text := units[pos]
+			prompt := reviewPrompt(dt.segmentPrompt(units, []int{pos}, targetLanguage, userPrompt, nil), text)
+			est.Review.Requests++
+			est.Review.InputTokens += EstimateTokens(buildSystemPrompt(targetLanguage, prompt)) + EstimateTokens(text)
+			est.Review.OutputTokens += EstimateTokens(text)
+		}
+	}
+	return est, nil
+}
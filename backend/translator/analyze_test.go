@@ -0,0 +1,92 @@
+package translator
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestAnalyzeEPUB(t *testing.T) {
+	english := []string{"He said that the ship was late again.", "She asked him when it would come.", "Nobody knew the answer to that."}
+	chinese := "这是一段已经是中文的文字，不需要翻译。"
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  testOPF,
+		"OEBPS/text/a.xhtml": testChapter(english...),
+		"OEBPS/text/b.xhtml": testChapter(chinese, english[0]),
+	})
+
+	client := &recordingClient{}
+	cache := newTestCache(t)
+	dt := &DocumentTranslator{Client: client, Cache: cache, Provider: "test/model"}
+
+	est, err := dt.AnalyzeEPUB(path, "Chinese", "")
+	if err != nil {
+		t.Fatalf("AnalyzeEPUB failed: %v", err)
+	}
+	if len(client.prompts) != 0 {
+		t.Errorf("Expected no translation requests, got %v", client.prompts)
+	}
+	if len(est.Chapters) != 2 || est.Chapters[0].File != "OEBPS/text/b.xhtml" || est.Chapters[0].Blocks != 2 || est.Chapters[1].Blocks != 3 {
+		t.Fatalf("Unexpected chapters: %+v", est.Chapters)
+	}
+	if est.Blocks != 5 || est.Characters == 0 || est.Tokens == 0 {
+		t.Errorf("Unexpected totals: %+v", est)
+	}
+	// 重复的文本块只计一次，中文块已是目标语言
+	if est.Units != 4 || est.SkippedUnits != 1 || est.PendingUnits != 3 || est.SourceLanguage != "en" {
+		t.Errorf("Unexpected units: %+v", est)
+	}
+	if est.Translate.Requests != 3 || est.Translate.InputTokens <= est.PendingTokens || est.Review != nil {
+		t.Errorf("Unexpected translate estimate: %+v, review %+v", est.Translate, est.Review)
+	}
+
+	// 批量翻译合并请求，审校逐段计算
+	dt.Options.BatchSize = 10
+	dt.Reviewer = &recordingClient{}
+	est, err = dt.AnalyzeEPUB(path, "Chinese", "")
+	if err != nil {
+		t.Fatalf("AnalyzeEPUB failed: %v", err)
+	}
+	if est.Translate.Requests != 1 || est.Review == nil || est.Review.Requests != 3 {
+		t.Errorf("Unexpected batched estimate: %+v, review %+v", est.Translate, est.Review)
+	}
+
+	// 翻译后再分析，全部命中缓存
+	dt.Options.BatchSize = 0
+	dt.Reviewer = nil
+	output := filepath.Join(t.TempDir(), "out.epub")
+	if _, err := dt.TranslateEPUB("analyze", path, output, "Chinese", "", "bilingual", nil, nil); err != nil {
+		t.Fatalf("TranslateEPUB failed: %v", err)
+	}
+	est, err = dt.AnalyzeEPUB(path, "Chinese", "")
+	if err != nil {
+		t.Fatalf("AnalyzeEPUB failed: %v", err)
+	}
+	if est.CachedUnits != 3 || est.PendingUnits != 0 || est.Translate.Requests != 0 {
+		t.Errorf("Expected all units cached, got %+v", est)
+	}
+}
+
+func TestAnalyzeEPUB_InlineMarkup(t *testing.T) {
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  testOPF,
+		"OEBPS/text/a.xhtml": testChapter(`Read <em>this</em> chapter and follow <a href="#n1">the note</a> carefully.`),
+		"OEBPS/text/b.xhtml": testChapter("Read this chapter and follow the note carefully."),
+	})
+
+	dt := &DocumentTranslator{Client: &recordingClient{}, Cache: newTestCache(t), Provider: "test/model"}
+	est, err := dt.AnalyzeEPUB(path, "Chinese", "")
+	if err != nil {
+		t.Fatalf("AnalyzeEPUB failed: %v", err)
+	}
+	if len(est.Chapters) != 2 {
+		t.Fatalf("Unexpected chapters: %+v", est.Chapters)
+	}
+	// 内联元素的占位符不计入字符数和 token 数
+	plain, marked := est.Chapters[0], est.Chapters[1]
+	if marked.Characters != plain.Characters || marked.Tokens != plain.Tokens {
+		t.Errorf("Expected markup to be excluded from counts, got %+v and %+v", marked, plain)
+	}
+	if est.PendingTokens != est.Tokens {
+		t.Errorf("Expected pending tokens %d to match total tokens %d", est.PendingTokens, est.Tokens)
+	}
+}
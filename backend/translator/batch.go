@@ -0,0 +1,209 @@
+package translator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+// ErrBatchMisaligned 批量翻译返回的结果与输入片段数量或编号不一致
+var ErrBatchMisaligned = errors.New("batch response misaligned")
+
+// batchSegment 批量请求中的一个编号片段
+type batchSegment struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+}
+
+// batchInstruction 批量模式下追加到系统提示词中的说明
+const batchInstruction = `You will receive a JSON array of numbered segments in the form [{"id": 1, "text": "..."}].
+Translate the "text" of every segment independently. Do not merge, split, reorder or skip segments.
+Return ONLY a JSON array with exactly one object per input segment, in the same order and with the same ids: [{"id": 1, "text": "<translation>"}].
+Do not wrap the JSON in code fences and do not add any explanations.`
+
+// packBatches 按条数和 token 预算把文本分组，返回每组在 texts 中的下标
+// maxSegments <= 1 时每组只有一个文本（即不启用批量）
+func packBatches(texts []string, maxSegments, maxTokens int) [][]int {
+	var groups [][]int
+	var current []int
+	currentTokens := 0
+
+	for i, text := range texts {
+		tokens := EstimateTokens(text)
+		full := len(current) > 0 &&
+			(maxSegments <= 1 || len(current) >= maxSegments ||
+				(maxTokens > 0 && currentTokens+tokens > maxTokens))
+		if full {
+			groups = append(groups, current)
+			current = nil
+			currentTokens = 0
+		}
+		current = append(current, i)
+		currentTokens += tokens
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+// translateBatch 把多个文本打包成一次请求翻译，返回与 texts 一一对应的译文及产生译文的提供商
+func (dt *DocumentTranslator) translateBatch(texts []string, targetLanguage, userPrompt string) ([]string, string, error) {
+	payload, err := batchPayload(texts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	response, provider, err := dt.translateStructured(payload, targetLanguage, batchPrompt(userPrompt))
+	if err != nil {
+		return nil, "", err
+	}
+
+	results, err := parseBatchResponse(response, len(texts))
+	return results, provider, err
+}
+
+// batchPayload 把文本编号后序列化为批量请求的 JSON 数组
+func batchPayload(texts []string) (string, error) {
+	segments := make([]batchSegment, len(texts))
+	for i, text := range texts {
+		segments[i] = batchSegment{ID: i + 1, Text: text}
+	}
+	payload, err := json.Marshal(segments)
+	if err != nil {
+		return "", fmt.Errorf("序列化批量请求失败: %w", err)
+	}
+	return string(payload), nil
+}
+
+// batchPrompt 返回批量请求的提示词：批量说明加上用户提示词
+func batchPrompt(userPrompt string) string {
+	if userPrompt == "" {
+		return batchInstruction
+	}
+	return batchInstruction + "\n" + userPrompt
+}
+
+// parseBatchResponse 解析并校验批量翻译的 JSON 数组响应
+func parseBatchResponse(response string, count int) ([]string, error) {
+	// 模型有时仍会加上 ```json 代码块或前后说明，只截取最外层的数组
+	start := strings.Index(response, "[")
+	end := strings.LastIndex(response, "]")
+	if start == -1 || end <= start {
+		return nil, fmt.Errorf("%w: response is not a JSON array", ErrBatchMisaligned)
+	}
+	raw := []byte(response[start : end+1])
+
+	results := make([]string, count)
+
+	var segments []batchSegment
+	if err := json.Unmarshal(raw, &segments); err == nil && (len(segments) == 0 || segments[0].ID != 0) {
+		if len(segments) != count {
+			return nil, fmt.Errorf("%w: expected %d segments, got %d", ErrBatchMisaligned, count, len(segments))
+		}
+		for i, seg := range segments {
+			if seg.ID != i+1 {
+				return nil, fmt.Errorf("%w: segment %d has id %d", ErrBatchMisaligned, i+1, seg.ID)
+			}
+			results[i] = strings.TrimSpace(seg.Text)
+		}
+	} else {
+		// 兼容直接返回字符串数组的情况，此时只能按数量校验
+		var texts []string
+		if err := json.Unmarshal(raw, &texts); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrBatchMisaligned, err)
+		}
+		if len(texts) != count {
+			return nil, fmt.Errorf("%w: expected %d segments, got %d", ErrBatchMisaligned, count, len(texts))
+		}
+		for i, text := range texts {
+			results[i] = strings.TrimSpace(text)
+		}
+	}
+
+	for i, result := range results {
+		if result == "" {
+			return nil, fmt.Errorf("%w: segment %d is empty", ErrBatchMisaligned, i+1)
+		}
+	}
+	return results, nil
+}
+
+// segmentResult 一个文本块的翻译结果
+type segmentResult struct {
+	Text     string
+	Provider string // 产生译文的提供商
+	// Issues 重试后仍未通过质量检查的问题，为空表示通过
+	Issues []QualityIssue
+}
+
+// translateGroup 翻译一组文本，返回成功翻译的 原文 -> 结果
+// 多个文本时先尝试批量请求，失败或结果错位时退回逐条翻译；批量结果中未通过质量检查的文本块也改为单独翻译
+func (dt *DocumentTranslator) translateGroup(texts []string, targetLanguage, userPrompt string) map[string]segmentResult {
+	results := make(map[string]segmentResult, len(texts))
+
+	if len(texts) > 1 {
+		translated, provider, err := dt.translateBatch(texts, targetLanguage, userPrompt)
+		if err == nil {
+			var retry []string
+			for i, text := range texts {
+				cleaned := CleanTranslation(text, translated[i])
+				if issues := qualityIssues(text, cleaned, targetLanguage); len(issues) > 0 {
+					log.Printf("批量译文未通过质量检查（%v），改为单独翻译: %s", issues, text)
+					retry = append(retry, text)
+					continue
+				}
+				results[text] = segmentResult{Text: cleaned, Provider: provider}
+			}
+			texts = retry
+		} else {
+			log.Printf("批量翻译 %d 个文本块失败，改为逐条翻译: %v", len(texts), err)
+		}
+	}
+
+	for _, text := range texts {
+		result, err := dt.translateSegment(text, targetLanguage, userPrompt)
+		if err != nil {
+			log.Printf("翻译文本失败: %s, 错误: %v", text, err)
+			continue
+		}
+		results[text] = result
+	}
+	return results
+}
+
+// qualityRetryInstruction 译文未通过质量检查、重新翻译时追加的说明
+const qualityRetryInstruction = `Your previous answer was rejected by an automatic check. Translate the complete text into the target language and return ONLY the translation, without any preamble, notes or surrounding quotes.`
+
+// translateSegment 翻译单个文本块，清理前置说明和包裹的引号后检查质量
+// 不合格时重新翻译，最多 maxQualityRetries 次；仍不合格时返回最后的译文并在 Issues 中标记
+func (dt *DocumentTranslator) translateSegment(text, targetLanguage, userPrompt string) (segmentResult, error) {
+	var result segmentResult
+	prompt := userPrompt
+	for attempt := 0; attempt <= maxQualityRetries; attempt++ {
+		translated, provider, err := dt.translate(text, targetLanguage, prompt)
+		if err != nil {
+			if attempt > 0 {
+				break // 保留上一次不合格的译文
+			}
+			return segmentResult{}, err
+		}
+
+		cleaned := CleanTranslation(text, translated)
+		result = segmentResult{Text: cleaned, Provider: provider, Issues: qualityIssues(text, cleaned, targetLanguage)}
+		if len(result.Issues) == 0 {
+			return result, nil
+		}
+		log.Printf("译文未通过质量检查（%v）: %s", result.Issues, text)
+
+		prompt = joinPrompt(userPrompt, qualityRetryInstruction)
+		if slices.Contains(result.Issues, IssuePlaceholders) {
+			prompt = joinPrompt(prompt, placeholderRetryInstruction)
+		}
+	}
+	log.Printf("重新翻译后仍未通过质量检查，保留译文并标记: %s", text)
+	return result, nil
+}
@@ -0,0 +1,120 @@
+package translator
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// batchEchoClient 把批量请求中的每个片段翻译为 "译:" + 原文
+type batchEchoClient struct {
+	calls    int
+	misalign bool
+}
+
+func (c *batchEchoClient) Translate(text, targetLanguage, userPrompt string) (string, error) {
+	c.calls++
+	if !strings.Contains(userPrompt, "JSON array") {
+		return "译:" + text, nil
+	}
+
+	var segments []batchSegment
+	if err := json.Unmarshal([]byte(text), &segments); err != nil {
+		return "", err
+	}
+	if c.misalign {
+		segments = segments[:len(segments)-1]
+	}
+	for i := range segments {
+		segments[i].Text = "译:" + segments[i].Text
+	}
+	out, _ := json.Marshal(segments)
+	return "```json\n" + string(out) + "\n```", nil
+}
+
+func TestPackBatches(t *testing.T) {
+	texts := []string{"aaaa", "bbbb", "cccc", "dddd", "eeee"}
+
+	if groups := packBatches(texts, 1, 0); len(groups) != 5 {
+		t.Errorf("Expected 5 groups without batching, got %d", len(groups))
+	}
+	if groups := packBatches(texts, 2, 0); len(groups) != 3 || len(groups[2]) != 1 {
+		t.Errorf("Expected groups of 2,2,1, got %v", groups)
+	}
+	// 每个文本约 1 个 token，预算 3 时每组最多 3 个
+	if groups := packBatches(texts, 10, 3); len(groups) != 2 || len(groups[0]) != 3 {
+		t.Errorf("Expected groups of 3,2 under token budget, got %v", groups)
+	}
+}
+
+func TestParseBatchResponse(t *testing.T) {
+	results, err := parseBatchResponse(`Here you go: [{"id":1,"text":"一"},{"id":2,"text":"二"}]`, 2)
+	if err != nil {
+		t.Fatalf("parseBatchResponse failed: %v", err)
+	}
+	if results[0] != "一" || results[1] != "二" {
+		t.Errorf("Unexpected results: %v", results)
+	}
+
+	if results, err := parseBatchResponse(`["一","二"]`, 2); err != nil || results[1] != "二" {
+		t.Errorf("Expected plain string array to be accepted, got %v, %v", results, err)
+	}
+
+	for _, response := range []string{
+		`[{"id":1,"text":"一"}]`,
+		`[{"id":2,"text":"二"},{"id":1,"text":"一"}]`,
+		`[{"id":1,"text":"一"},{"id":2,"text":""}]`,
+		`not json`,
+	} {
+		if _, err := parseBatchResponse(response, 2); !errors.Is(err, ErrBatchMisaligned) {
+			t.Errorf("Expected ErrBatchMisaligned for %q, got %v", response, err)
+		}
+	}
+}
+
+func TestBatchTranslate(t *testing.T) {
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCache failed: %v", err)
+	}
+
+	client := &batchEchoClient{}
+	dt := &DocumentTranslator{Client: client, Cache: cache, Options: TranslateOptions{BatchSize: 10}}
+
+	results, err := dt.BatchTranslate([]string{"one", "two", "", "one", "three"}, "Chinese", "")
+	if err != nil {
+		t.Fatalf("BatchTranslate failed: %v", err)
+	}
+	expected := []string{"译:one", "译:two", "", "译:one", "译:three"}
+	for i := range expected {
+		if results[i] != expected[i] {
+			t.Errorf("Result %d: expected %q, got %q", i, expected[i], results[i])
+		}
+	}
+	if client.calls != 1 {
+		t.Errorf("Expected a single batched request, got %d", client.calls)
+	}
+}
+
+func TestBatchTranslate_MisalignedFallback(t *testing.T) {
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCache failed: %v", err)
+	}
+
+	client := &batchEchoClient{misalign: true}
+	dt := &DocumentTranslator{Client: client, Cache: cache, Options: TranslateOptions{BatchSize: 10}}
+
+	results, err := dt.BatchTranslate([]string{"one", "two", "three"}, "Chinese", "")
+	if err != nil {
+		t.Fatalf("BatchTranslate failed: %v", err)
+	}
+	if results[2] != "译:three" {
+		t.Errorf("Expected per-segment fallback result, got %q", results[2])
+	}
+	// 1 次批量请求 + 3 次逐条请求
+	if client.calls != 4 {
+		t.Errorf("Expected 4 calls, got %d", client.calls)
+	}
+}
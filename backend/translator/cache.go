@@ -16,12 +16,16 @@ type Cache struct {
 	data map[string]CacheEntry
 	dir  string // 缓存目录
 	mu   sync.RWMutex
+
+	done      chan struct{} // 关闭后停止清理协程
+	closeOnce sync.Once
 }
 
 // CacheEntry 缓存条目
 type CacheEntry struct {
 	Value      string    `json:"value"`
 	ExpireTime time.Time `json:"expire_time"`
+	Provider   string    `json:"provider,omitempty"` // 产生该译文的提供商
 }
 
 // NewCache 创建新的缓存
@@ -34,6 +38,7 @@ func NewCache(dir string) (*Cache, error) {
 	cache := &Cache{
 		data: make(map[string]CacheEntry),
 		dir:  dir,
+		done: make(chan struct{}),
 	}
 	// 启动清理协程
 	go cache.cleanup()
@@ -48,25 +53,31 @@ func (c *Cache) DisableCache() {
 
 // Get 获取缓存值
 func (c *Cache) Get(key string) (string, bool) {
+	entry, ok := c.GetEntry(key)
+	return entry.Value, ok
+}
+
+// GetEntry 获取缓存条目（包含产生译文的提供商）
+func (c *Cache) GetEntry(key string) (CacheEntry, bool) {
 	c.mu.RLock()
 	// 先查内存
 	if entry, exists := c.data[key]; exists {
 		c.mu.RUnlock()
 		if time.Now().After(entry.ExpireTime) {
-			return "", false
+			return CacheEntry{}, false
 		}
-		return entry.Value, true
+		return entry, true
 	}
 	c.mu.RUnlock()
 
 	// 查磁盘
 	entry, exists := c.loadFromDisk(key)
 	if !exists {
-		return "", false
+		return CacheEntry{}, false
 	}
 
 	if time.Now().After(entry.ExpireTime) {
-		return "", false
+		return CacheEntry{}, false
 	}
 
 	// 回填内存
@@ -74,17 +85,23 @@ func (c *Cache) Get(key string) (string, bool) {
 	c.data[key] = entry
 	c.mu.Unlock()
 
-	return entry.Value, true
+	return entry, true
 }
 
 // Set 设置缓存值
 func (c *Cache) Set(key, value string) {
+	c.SetEntry(key, value, "")
+}
+
+// SetEntry 设置缓存值并记录产生译文的提供商
+func (c *Cache) SetEntry(key, value, provider string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	entry := CacheEntry{
 		Value:      value,
 		ExpireTime: time.Now().Add(24 * 7 * time.Hour), // 延长到7天过期，避免太频繁失效
+		Provider:   provider,
 	}
 
 	c.data[key] = entry
@@ -125,7 +142,12 @@ func (c *Cache) cleanup() {
 	ticker := time.NewTicker(12 * time.Hour) // 降低清理频率
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
 		c.mu.Lock()
 		now := time.Now()
 		// 清理内存
@@ -139,37 +161,105 @@ func (c *Cache) cleanup() {
 	}
 }
 
+// Close 停止后台清理协程，用于只短暂使用的缓存（如翻译前的估算）
+func (c *Cache) Close() {
+	c.closeOnce.Do(func() { close(c.done) })
+}
+
 // CacheKey 生成缓存键
-func CacheKey(text, targetLanguage, userPrompt string) string {
-	data := fmt.Sprintf("%s|%s|%s", text, targetLanguage, userPrompt)
+// userPrompt 为实际使用的提示词（包含相关的术语和上下文），因此修改术语表会使受影响的译文失效；
+// provider 为提供商（链）标识，不同模型的译文互不复用
+func CacheKey(text, targetLanguage, userPrompt, provider string) string {
+	data := fmt.Sprintf("%s|%s|%s|%s", text, targetLanguage, userPrompt, provider)
 	hash := sha256.Sum256([]byte(data))
 	return hex.EncodeToString(hash[:])
 }
 
 // SaveProgressMap 保存任务进度映射
 func (c *Cache) SaveProgressMap(taskID string, data map[string]string) error {
-	filePath := filepath.Join(c.dir, fmt.Sprintf("progress_%s.json", taskID))
+	return c.saveTaskMap(fmt.Sprintf("progress_%s.json", taskID), data)
+}
+
+// LoadProgressMap 加载任务进度映射
+func (c *Cache) LoadProgressMap(taskID string) (map[string]string, error) {
+	return c.loadTaskMap(fmt.Sprintf("progress_%s.json", taskID))
+}
+
+// SaveProviderMap 保存任务中每个文本块由哪个提供商翻译（原文 -> 提供商），用于审计
+func (c *Cache) SaveProviderMap(taskID string, data map[string]string) error {
+	return c.saveTaskMap(fmt.Sprintf("providers_%s.json", taskID), data)
+}
+
+// LoadProviderMap 加载任务的提供商记录
+func (c *Cache) LoadProviderMap(taskID string) (map[string]string, error) {
+	return c.loadTaskMap(fmt.Sprintf("providers_%s.json", taskID))
+}
+
+// SaveMatchMap 保存任务中每个文本块的翻译记忆库匹配度（原文 -> 相似度）
+func (c *Cache) SaveMatchMap(taskID string, data map[string]float64) error {
+	return c.saveTaskMap(fmt.Sprintf("matches_%s.json", taskID), data)
+}
+
+// LoadMatchMap 加载任务的匹配度记录，文件不存在时返回 nil
+func (c *Cache) LoadMatchMap(taskID string) (map[string]float64, error) {
+	var matches map[string]float64
+	if err := c.loadTaskFile(fmt.Sprintf("matches_%s.json", taskID), &matches); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// SaveDraftMap 保存任务中审校过的文本块的初译和审校译文（原文 -> 记录），便于之后比较
+func (c *Cache) SaveDraftMap(taskID string, data map[string]ReviewDraft) error {
+	return c.saveTaskMap(fmt.Sprintf("drafts_%s.json", taskID), data)
+}
+
+// LoadDraftMap 加载任务的审校记录，文件不存在时返回 nil
+func (c *Cache) LoadDraftMap(taskID string) (map[string]ReviewDraft, error) {
+	var drafts map[string]ReviewDraft
+	if err := c.loadTaskFile(fmt.Sprintf("drafts_%s.json", taskID), &drafts); err != nil {
+		return nil, err
+	}
+	return drafts, nil
+}
+
+// saveTaskMap 保存任务相关的映射文件
+func (c *Cache) saveTaskMap(name string, data any) error {
+	filePath := filepath.Join(c.dir, name)
 	jsonData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return fmt.Errorf("序列化进度数据失败: %w", err)
 	}
-	return os.WriteFile(filePath, jsonData, 0644)
+	// 先写临时文件再重命名，避免并发翻译期间中断时留下不完整的进度文件
+	tmpPath := filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, jsonData, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filePath)
 }
 
-// LoadProgressMap 加载任务进度映射
-func (c *Cache) LoadProgressMap(taskID string) (map[string]string, error) {
-	filePath := filepath.Join(c.dir, fmt.Sprintf("progress_%s.json", taskID))
+// loadTaskMap 加载任务相关的映射文件，文件不存在时返回 nil
+func (c *Cache) loadTaskMap(name string) (map[string]string, error) {
+	var progress map[string]string
+	if err := c.loadTaskFile(name, &progress); err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+// loadTaskFile 把任务相关的 JSON 文件解析到 v，文件不存在时不修改 v
+func (c *Cache) loadTaskFile(name string, v any) error {
+	filePath := filepath.Join(c.dir, name)
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, nil // 文件不存在，不是错误
+			return nil // 文件不存在，不是错误
 		}
-		return nil, fmt.Errorf("读取进度文件失败: %w", err)
+		return fmt.Errorf("读取进度文件失败: %w", err)
 	}
 
-	var progress map[string]string
-	if err := json.Unmarshal(data, &progress); err != nil {
-		return nil, fmt.Errorf("解析进度数据失败: %w", err)
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("解析进度数据失败: %w", err)
 	}
-	return progress, nil
+	return nil
 }
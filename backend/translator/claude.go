@@ -0,0 +1,164 @@
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// claudeAPIVersion Anthropic Messages API 版本
+	claudeAPIVersion = "2023-06-01"
+	// claudeDefaultMaxTokens Messages API 要求必须提供 max_tokens
+	claudeDefaultMaxTokens = 4096
+)
+
+// Anthropic Messages API Request/Response structures
+type ClaudeMessagesRequest struct {
+	Model       string    `json:"model"`
+	System      string    `json:"system,omitempty"`
+	Messages    []Message `json:"messages"`
+	MaxTokens   int       `json:"max_tokens"`
+	Temperature float64   `json:"temperature"`
+	TopP        *float64  `json:"top_p,omitempty"`
+}
+
+type ClaudeMessagesResponse struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Role    string `json:"role"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Error      struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// ClaudeClient Anthropic Claude 翻译客户端
+type ClaudeClient struct {
+	APIKey      string
+	BaseURL     string
+	Model       string
+	Temperature float64
+	MaxTokens   int
+	TopP        *float64
+	Client      *http.Client
+	Timeout     time.Duration
+}
+
+// NewClaudeClient 创建新的 Claude 客户端
+func NewClaudeClient(config ProviderConfig) *ClaudeClient {
+	maxTokens := config.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = claudeDefaultMaxTokens
+	}
+
+	var topP *float64
+	if v, ok := config.ExtraFloat("top_p"); ok {
+		topP = &v
+	}
+
+	timeout := config.HTTPTimeout(60 * time.Second)
+	return &ClaudeClient{
+		APIKey:      config.APIKey,
+		BaseURL:     config.APIURL,
+		Model:       config.Model,
+		Temperature: config.TemperatureOr(0.3),
+		MaxTokens:   maxTokens,
+		TopP:        topP,
+		Client: &http.Client{
+			Timeout: timeout,
+		},
+		Timeout: timeout,
+	}
+}
+
+// Translate 使用 Claude Messages API 翻译文本
+func (c *ClaudeClient) Translate(text, targetLanguage, userPrompt string) (string, error) {
+	// 系统提示词放在顶层 system 字段，而不是 messages 中
+	reqBody := ClaudeMessagesRequest{
+		Model:  c.Model,
+		System: buildSystemPrompt(targetLanguage, userPrompt),
+		Messages: []Message{
+			{Role: "user", Content: text},
+		},
+		MaxTokens:   c.MaxTokens,
+		Temperature: c.Temperature,
+		TopP:        c.TopP,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("marshal request failed: %w", err)
+	}
+
+	// 用户可能给出 https://api.anthropic.com/v1 或完整的 /v1/messages 地址
+	apiURL := strings.TrimSuffix(c.BaseURL, "/")
+	if !strings.HasSuffix(apiURL, "/messages") {
+		apiURL += "/messages"
+	}
+
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("create request failed: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("anthropic-version", claudeAPIVersion)
+	if c.APIKey != "" {
+		req.Header.Set("x-api-key", c.APIKey)
+	}
+
+	// 发送请求
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("send request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newAPIError(resp)
+	}
+
+	// 解析响应
+	var messagesResp ClaudeMessagesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&messagesResp); err != nil {
+		return "", fmt.Errorf("decode response failed: %w", err)
+	}
+
+	if messagesResp.Error.Message != "" {
+		return "", fmt.Errorf("API error: %s", messagesResp.Error.Message)
+	}
+
+	// 模型拒绝回答时 stop_reason 为 refusal
+	if messagesResp.StopReason == "refusal" {
+		return "", fmt.Errorf("%w: stop_reason=refusal", ErrContentBlocked)
+	}
+	// max_tokens 等表示输出被截断
+	switch messagesResp.StopReason {
+	case "", "end_turn", "stop_sequence":
+	default:
+		return "", fmt.Errorf("%w: stop_reason=%s", ErrIncompleteOutput, messagesResp.StopReason)
+	}
+
+	// 拼接所有 text 类型的内容块
+	var result strings.Builder
+	for _, block := range messagesResp.Content {
+		if block.Type == "text" {
+			result.WriteString(block.Text)
+		}
+	}
+
+	if result.Len() == 0 {
+		return "", fmt.Errorf("no translation returned")
+	}
+
+	return strings.TrimSpace(result.String()), nil
+}
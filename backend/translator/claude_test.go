@@ -0,0 +1,85 @@
+package translator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClaudeClient_Translate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("Expected path '/v1/messages', got %s", r.URL.Path)
+		}
+
+		// 验证请求头
+		if r.Header.Get("x-api-key") != "test-api-key" {
+			t.Errorf("Expected x-api-key header 'test-api-key', got %s", r.Header.Get("x-api-key"))
+		}
+		if r.Header.Get("anthropic-version") != claudeAPIVersion {
+			t.Errorf("Expected anthropic-version '%s', got %s", claudeAPIVersion, r.Header.Get("anthropic-version"))
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("Expected no Authorization header, got %s", r.Header.Get("Authorization"))
+		}
+
+		// 验证请求体
+		var req ClaudeMessagesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+
+		if req.Model != "claude-3-5-sonnet-20241022" {
+			t.Errorf("Expected model 'claude-3-5-sonnet-20241022', got %s", req.Model)
+		}
+		if !strings.Contains(req.System, "Chinese") {
+			t.Errorf("Expected system prompt to mention target language, got %s", req.System)
+		}
+		if len(req.Messages) != 1 || req.Messages[0].Role != "user" || req.Messages[0].Content != "Hello" {
+			t.Errorf("Expected single user message 'Hello', got %+v", req.Messages)
+		}
+		if req.MaxTokens != claudeDefaultMaxTokens {
+			t.Errorf("Expected max_tokens %d, got %d", claudeDefaultMaxTokens, req.MaxTokens)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"你好"}],"stop_reason":"end_turn"}`))
+	}))
+	defer server.Close()
+
+	client := NewClaudeClient(ProviderConfig{
+		Type:   ProviderClaude,
+		APIKey: "test-api-key",
+		APIURL: server.URL + "/v1",
+		Model:  "claude-3-5-sonnet-20241022",
+	})
+
+	result, err := client.Translate("Hello", "Chinese", "")
+	if err != nil {
+		t.Fatalf("Translate failed: %v", err)
+	}
+
+	if result != "你好" {
+		t.Errorf("Expected translation '你好', got '%s'", result)
+	}
+}
+
+func TestClaudeClient_Translate_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClaudeClient(ProviderConfig{APIKey: "invalid-key", APIURL: server.URL + "/v1/messages"})
+
+	_, err := client.Translate("Hello", "Chinese", "")
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected error message containing '401', got '%v'", err)
+	}
+}
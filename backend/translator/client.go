@@ -41,12 +41,18 @@ func (c *TranslatorClient) Translate(text, targetLanguage, userPrompt string) (s
 type Message struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+	// Refusal 模型拒绝回答时的说明（仅出现在响应中）
+	Refusal string `json:"refusal,omitempty"`
 }
 
 type ChatCompletionRequest struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	Temperature float64   `json:"temperature"`
+	Model          string      `json:"model"`
+	Messages       []Message   `json:"messages"`
+	Temperature    float64     `json:"temperature"`
+	MaxTokens      int         `json:"max_tokens,omitempty"`
+	TopP           *float64    `json:"top_p,omitempty"`
+	Seed           *int        `json:"seed,omitempty"`
+	ResponseFormat interface{} `json:"response_format,omitempty"`
 }
 
 type ChatCompletionResponse struct {
@@ -65,49 +71,108 @@ type ChatCompletionResponse struct {
 	} `json:"error"`
 }
 
+// APIError 提供商返回的非 200 响应，保留状态码和响应头供重试逻辑使用
+type APIError struct {
+	StatusCode int
+	Body       string
+	Header     http.Header
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// newAPIError 读取响应体并构造 APIError
+func newAPIError(resp *http.Response) *APIError {
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Body:       string(bodyBytes),
+		Header:     resp.Header,
+	}
+}
+
 // LLMClient LLM翻译客户端
 type LLMClient struct {
 	APIKey  string
 	BaseURL string
 	Model   string
+	Config  ProviderConfig
 	Client  *http.Client
 	Timeout time.Duration
 }
 
 // NewLLMClient 创建新的LLM客户端
 func NewLLMClient(apiKey, baseURL, model string) *LLMClient {
+	return NewLLMClientWithConfig(ProviderConfig{
+		APIKey: apiKey,
+		APIURL: baseURL,
+		Model:  model,
+	})
+}
+
+// NewLLMClientWithConfig 根据完整的提供商配置创建LLM客户端
+func NewLLMClientWithConfig(config ProviderConfig) *LLMClient {
+	timeout := config.HTTPTimeout(60 * time.Second)
 	return &LLMClient{
-		APIKey:  apiKey,
-		BaseURL: baseURL,
-		Model:   model,
+		APIKey:  config.APIKey,
+		BaseURL: config.APIURL,
+		Model:   config.Model,
+		Config:  config,
 		Client: &http.Client{
-			Timeout: 60 * time.Second,
+			Timeout: timeout,
 		},
-		Timeout: 60 * time.Second,
+		Timeout: timeout,
 	}
 }
 
-// Translate 使用LLM翻译文本
-func (c *LLMClient) Translate(text, targetLanguage, userPrompt string) (string, error) {
-	// 构造系统提示词
-	systemPrompt := "You are a professional translator. Translate the following text into " + targetLanguage + "."
-	if userPrompt != "" {
-		systemPrompt += "\n" + userPrompt
-	} else {
-		systemPrompt += "\nOnly return the translated text without any explanations or extra quotes."
-	}
-
-	// 构造请求体
+// buildRequestBody 构造请求体，并把 Extra 中的其他参数合并进去
+func (c *LLMClient) buildRequestBody(text, targetLanguage, userPrompt string) ([]byte, error) {
 	reqBody := ChatCompletionRequest{
 		Model: c.Model,
 		Messages: []Message{
-			{Role: "system", Content: systemPrompt},
+			{Role: "system", Content: buildSystemPrompt(targetLanguage, userPrompt)},
 			{Role: "user", Content: text},
 		},
-		Temperature: 0.3,
+		Temperature:    c.Config.TemperatureOr(0.3),
+		MaxTokens:      c.Config.MaxTokens,
+		ResponseFormat: c.Config.Extra["response_format"],
+	}
+	if topP, ok := c.Config.ExtraFloat("top_p"); ok {
+		reqBody.TopP = &topP
+	}
+	if seed, ok := c.Config.ExtraInt("seed"); ok {
+		reqBody.Seed = &seed
 	}
 
 	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Config.Extra) == 0 {
+		return jsonData, nil
+	}
+
+	// 透传其余 Extra 参数，但不覆盖上面已经设置的字段
+	var body map[string]interface{}
+	if err := json.Unmarshal(jsonData, &body); err != nil {
+		return nil, err
+	}
+	for key, value := range c.Config.Extra {
+		if internalExtraKeys[key] {
+			continue
+		}
+		if _, exists := body[key]; !exists {
+			body[key] = value
+		}
+	}
+	return json.Marshal(body)
+}
+
+// Translate 使用LLM翻译文本
+func (c *LLMClient) Translate(text, targetLanguage, userPrompt string) (string, error) {
+	// 构造请求体
+	jsonData, err := c.buildRequestBody(text, targetLanguage, userPrompt)
 	if err != nil {
 		return "", fmt.Errorf("marshal request failed: %w", err)
 	}
@@ -144,8 +209,7 @@ func (c *LLMClient) Translate(text, targetLanguage, userPrompt string) (string,
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return "", newAPIError(resp)
 	}
 
 	// 解析响应
@@ -162,5 +226,29 @@ func (c *LLMClient) Translate(text, targetLanguage, userPrompt string) (string,
 		return "", fmt.Errorf("no translation returned")
 	}
 
-	return strings.TrimSpace(completionResp.Choices[0].Message.Content), nil
+	choice := completionResp.Choices[0]
+	if choice.FinishReason == "content_filter" {
+		return "", fmt.Errorf("%w: finish_reason=content_filter", ErrContentBlocked)
+	}
+	if choice.Message.Refusal != "" {
+		return "", fmt.Errorf("%w: %s", ErrContentBlocked, choice.Message.Refusal)
+	}
+	// length 表示达到 max_tokens 被截断；部分兼容服务不返回 finish_reason
+	if choice.FinishReason != "" && choice.FinishReason != "stop" {
+		return "", fmt.Errorf("%w: finish_reason=%s", ErrIncompleteOutput, choice.FinishReason)
+	}
+
+	return strings.TrimSpace(choice.Message.Content), nil
+}
+
+// outputOnlyInstruction 要求模型只返回译文，始终包含在系统提示词中
+const outputOnlyInstruction = "Only return the translated text without any explanations or extra quotes."
+
+// buildSystemPrompt 构造系统提示词（各客户端共用），用户提示词和组合的各片段跟在固定说明之后
+func buildSystemPrompt(targetLanguage, userPrompt string) string {
+	systemPrompt := "You are a professional translator. Translate the following text into " + targetLanguage + ".\n" + outputOnlyInstruction
+	if userPrompt != "" {
+		systemPrompt += "\n" + userPrompt
+	}
+	return systemPrompt
 }
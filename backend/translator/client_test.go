@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLLMClient_Translate(t *testing.T) {
@@ -94,3 +95,73 @@ func TestLLMClient_Translate_Error(t *testing.T) {
 		t.Errorf("Expected error message containing 'Invalid API Key' or '400', got '%v'", err)
 	}
 }
+
+func TestLLMClient_Translate_ProviderConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+
+		// 温度为 0 时也必须显式发送
+		if body["temperature"] != float64(0) {
+			t.Errorf("Expected temperature 0, got %v", body["temperature"])
+		}
+		if body["max_tokens"] != float64(512) {
+			t.Errorf("Expected max_tokens 512, got %v", body["max_tokens"])
+		}
+		if body["seed"] != float64(42) {
+			t.Errorf("Expected seed 42, got %v", body["seed"])
+		}
+		if body["top_p"] != 0.9 {
+			t.Errorf("Expected top_p 0.9, got %v", body["top_p"])
+		}
+		if format, ok := body["response_format"].(map[string]interface{}); !ok || format["type"] != "text" {
+			t.Errorf("Expected response_format {type: text}, got %v", body["response_format"])
+		}
+		if body["presence_penalty"] != 0.5 {
+			t.Errorf("Expected passthrough presence_penalty 0.5, got %v", body["presence_penalty"])
+		}
+		if _, ok := body["keep_alive"]; ok {
+			t.Error("Expected internal key keep_alive not to be sent")
+		}
+		if body["model"] != "gpt-4o" {
+			t.Errorf("Expected model not to be overridden by Extra, got %v", body["model"])
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"你好"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	temperature := 0.0
+	client := NewLLMClientWithConfig(ProviderConfig{
+		Type:        ProviderOpenAI,
+		APIKey:      "test-api-key",
+		APIURL:      server.URL,
+		Model:       "gpt-4o",
+		Temperature: &temperature,
+		MaxTokens:   512,
+		Timeout:     5,
+		Extra: map[string]interface{}{
+			"seed":             float64(42),
+			"top_p":            0.9,
+			"response_format":  map[string]interface{}{"type": "text"},
+			"presence_penalty": 0.5,
+			"keep_alive":       "5m",
+			"model":            "should-not-override",
+		},
+	})
+
+	if client.Client.Timeout != 5*time.Second {
+		t.Errorf("Expected timeout 5s, got %s", client.Client.Timeout)
+	}
+
+	result, err := client.Translate("Hello", "Chinese", "")
+	if err != nil {
+		t.Fatalf("Translate failed: %v", err)
+	}
+	if result != "你好" {
+		t.Errorf("Expected translation '你好', got '%s'", result)
+	}
+}
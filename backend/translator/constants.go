@@ -1,6 +1,9 @@
 package translator
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 // ProviderType 翻译提供商类型
 type ProviderType string
@@ -33,10 +36,90 @@ type ProviderConfig struct {
 	APIKey      string                 `json:"api_key"`
 	APIURL      string                 `json:"api_url"`
 	Model       string                 `json:"model"`
-	Temperature float64                `json:"temperature"`
+	Temperature *float64               `json:"temperature"`
 	MaxTokens   int                    `json:"max_tokens"`
+	Timeout     int                    `json:"timeout"` // 请求超时（秒）
+	RateLimit   RateLimit              `json:"rate_limit"`
 	Extra       map[string]interface{} `json:"extra"`
+	// Fallbacks 按顺序尝试的备用提供商，当前提供商失败、拒绝或输出不合格时使用
+	Fallbacks []ProviderConfig `json:"fallbacks,omitempty"`
+}
+
+// Label 提供商标识，用于审计记录和缓存键，如 openai/gpt-4o-mini
+func (c ProviderConfig) Label() string {
+	return string(c.Type) + "/" + c.Model
+}
+
+// internalExtraKeys 由 etrans 自身消费、不应透传到请求体的 Extra 参数
+var internalExtraKeys = map[string]bool{
+	"auth_mode":   true,
+	"num_ctx":     true,
+	"num_predict": true,
+	"keep_alive":  true,
+	// 重试策略
+	"max_retries":         true,
+	"retry_base_delay_ms": true,
+	"retry_max_delay_ms":  true,
+	"retry_budget":        true,
+}
+
+// TemperatureOr 返回配置的温度，未设置时返回默认值
+func (c ProviderConfig) TemperatureOr(def float64) float64 {
+	if c.Temperature == nil {
+		return def
+	}
+	return *c.Temperature
+}
+
+// HTTPTimeout 返回请求超时，未设置时返回默认值
+func (c ProviderConfig) HTTPTimeout(def time.Duration) time.Duration {
+	if c.Timeout <= 0 {
+		return def
+	}
+	return time.Duration(c.Timeout) * time.Second
+}
+
+// ExtraString 读取 Extra 中的字符串参数
+func (c ProviderConfig) ExtraString(key string) (string, bool) {
+	v, ok := c.Extra[key]
+	if !ok {
+		return "", false
+	}
+	s, ok := v.(string)
+	return s, ok
+}
+
+// ExtraFloat 读取 Extra 中的数值参数（JSON 数字解析后为 float64）
+func (c ProviderConfig) ExtraFloat(key string) (float64, bool) {
+	switch v := c.Extra[key].(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// ExtraInt 读取 Extra 中的整数参数
+func (c ProviderConfig) ExtraInt(key string) (int, bool) {
+	v, ok := c.ExtraFloat(key)
+	return int(v), ok
 }
 
 // ErrPaused 任务已暂停
 var ErrPaused = errors.New("task paused")
+
+// ErrGlossaryReview 已生成建议术语表，任务等待用户审核后继续
+var ErrGlossaryReview = errors.New("glossary awaiting review")
+
+// ErrContentBlocked 提供商因内容安全策略拒绝翻译
+var ErrContentBlocked = errors.New("content blocked by provider")
+
+// ErrIncompleteOutput 输出未正常结束（如达到 max_tokens 被截断）
+var ErrIncompleteOutput = errors.New("output incomplete")
+
+// ErrEmptyTranslation 提供商返回了空译文
+var ErrEmptyTranslation = errors.New("empty translation")
@@ -0,0 +1,108 @@
+package translator
+
+import (
+	"slices"
+	"strings"
+)
+
+// contextInstruction 上下文段落的说明，放在系统提示词中
+const contextInstruction = `The paragraphs between <context> and </context> come right before the text you are given. They are provided for reference only, to keep names, pronouns, tense and terminology consistent.
+Do NOT translate, repeat or mention the context. Translate only the text in the user message.`
+
+// contextWindow 返回文档顺序中第 index 个文本块之前最多 k 个文本块的下标
+func contextWindow(blocks []string, index, k int) []int {
+	start := index - k
+	if start < 0 {
+		start = 0
+	}
+	var window []int
+	for i := start; i < index; i++ {
+		if blocks[i] != "" {
+			window = append(window, i)
+		}
+	}
+	return window
+}
+
+// buildContextPrompt 把上下文段落（以及已有的译文）追加到用户提示词之后
+// translations 为空或缺少某段译文时只提供原文
+func buildContextPrompt(userPrompt string, sources []string, translations map[string]string) string {
+	return joinPrompt(userPrompt, contextBlock(sources, translations))
+}
+
+// contextBlock 返回上下文段落的提示词片段，没有上下文时返回空
+func contextBlock(sources []string, translations map[string]string) string {
+	if len(sources) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(contextInstruction)
+	b.WriteString("\n<context>\n")
+	for _, source := range sources {
+		b.WriteString("<source>")
+		b.WriteString(source)
+		b.WriteString("</source>\n")
+		if translated, ok := translations[source]; ok {
+			b.WriteString("<translation>")
+			b.WriteString(translated)
+			b.WriteString("</translation>\n")
+		}
+	}
+	b.WriteString("</context>")
+	return b.String()
+}
+
+// segmentPrompt 返回文档中一组连续文本块（positions 为其在 blocks 中的下标）实际使用的提示词：
+// 用户提示词、这些文本块中出现的术语、翻译记忆库的参考译文，以及第一个文本块之前的上下文；
+// 文本块包含内联元素的占位符时附加占位符说明
+// 设置了提示词模板时由模板组合各部分；translations 为按下标记录的译文，只在 ContextTranslations 时使用，调用方需保证其不被并发修改
+func (dt *DocumentTranslator) segmentPrompt(blocks []string, positions []int, targetLanguage, userPrompt string, translations map[int]string) string {
+	texts := make([]string, len(positions))
+	for i, pos := range positions {
+		texts[i] = blocks[pos]
+	}
+	parts := promptParts{
+		Glossary:  dt.Options.Glossary.Prompt(texts...),
+		Reference: dt.referenceBlock(texts...),
+	}
+	if len(positions) > 0 {
+		parts.Chapter = dt.book.chapter(positions[0])
+	}
+	parts.SourceLanguage = dt.segmentLanguage(texts)
+
+	if k := dt.Options.ContextParagraphs; k > 0 && len(positions) > 0 {
+		window := contextWindow(blocks, positions[0], k)
+		sources := make([]string, len(window))
+		var translated map[string]string
+		if dt.Options.ContextTranslations {
+			translated = make(map[string]string, len(window))
+		}
+		for i, idx := range window {
+			sources[i] = blocks[idx]
+			if text, ok := translations[idx]; ok && translated != nil {
+				translated[blocks[idx]] = text
+			}
+		}
+		parts.Context = contextBlock(sources, translated)
+	}
+	prompt := dt.composePrompt(targetLanguage, userPrompt, parts)
+	if slices.ContainsFunc(texts, hasPlaceholders) {
+		prompt = joinPrompt(prompt, placeholderInstruction)
+	}
+	return prompt
+}
+
+// contextComplete 判断第 index 个文本块的上下文译文是否都已就绪
+// 未就绪时提示词还会变化，不能提前用缓存键查找
+func (dt *DocumentTranslator) contextComplete(blocks []string, index int, translations map[int]string) bool {
+	if dt.Options.ContextParagraphs <= 0 || !dt.Options.ContextTranslations {
+		return true
+	}
+	for _, idx := range contextWindow(blocks, index, dt.Options.ContextParagraphs) {
+		if _, ok := translations[idx]; !ok {
+			return false
+		}
+	}
+	return true
+}
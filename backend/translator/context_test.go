@@ -0,0 +1,151 @@
+package translator
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// pseudoTranslate 把拉丁字母逐个替换为汉字，生成可预测且能通过质量检查的“中文译文”
+func pseudoTranslate(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return '一' + (r - 'a')
+		case r >= 'A' && r <= 'Z':
+			return '乙' + (r - 'A')
+		}
+		return r
+	}, text)
+}
+
+// recordingClient 记录每次请求的提示词，返回 pseudoTranslate(原文)
+type recordingClient struct {
+	mu      sync.Mutex
+	prompts map[string]string
+}
+
+func (c *recordingClient) Translate(text, targetLanguage, userPrompt string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.prompts == nil {
+		c.prompts = make(map[string]string)
+	}
+	c.prompts[text] = userPrompt
+	return pseudoTranslate(text), nil
+}
+
+func TestBuildContextPrompt(t *testing.T) {
+	if got := buildContextPrompt("Be formal.", nil, nil); got != "Be formal." {
+		t.Errorf("Expected prompt unchanged without context, got %q", got)
+	}
+
+	got := buildContextPrompt("Be formal.", []string{"One.", "Two."}, map[string]string{"One.": "一。"})
+	for _, want := range []string{"Be formal.", "<context>", "<source>One.</source>\n<translation>一。</translation>", "<source>Two.</source>\n</context>"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected prompt to contain %q, got %q", want, got)
+		}
+	}
+}
+
+func TestTranslateEPUB_Context(t *testing.T) {
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  testOPF,
+		"OEBPS/text/a.xhtml": testChapter("Third paragraph."),
+		"OEBPS/text/b.xhtml": testChapter("First paragraph.", "Second paragraph."),
+	})
+
+	client := &recordingClient{}
+	cache := newTestCache(t)
+	dt := &DocumentTranslator{
+		Client:   client,
+		Cache:    cache,
+		Provider: "test/model",
+		Options:  TranslateOptions{ContextParagraphs: 1, ContextTranslations: true},
+	}
+
+	output := filepath.Join(t.TempDir(), "out.epub")
+	if _, err := dt.TranslateEPUB("ctx", path, output, "Chinese", "", "bilingual", nil, nil); err != nil {
+		t.Fatalf("TranslateEPUB failed: %v", err)
+	}
+
+	if prompt := client.prompts["First paragraph."]; prompt != "" {
+		t.Errorf("Expected no context for the first paragraph, got %q", prompt)
+	}
+	// 上下文来自 spine 顺序中的前一段，且附带其译文
+	prompt := client.prompts["Third paragraph."]
+	if !strings.Contains(prompt, "<source>Second paragraph.</source>") || !strings.Contains(prompt, "<translation>"+pseudoTranslate("Second paragraph.")+"</translation>") {
+		t.Errorf("Expected previous paragraph as context, got %q", prompt)
+	}
+	if strings.Contains(prompt, "First paragraph.") {
+		t.Errorf("Expected context window of 1 paragraph, got %q", prompt)
+	}
+
+	// 缓存键包含上下文：相同配置可以命中，不带上下文时不会误用
+	if _, ok := cache.Get(CacheKey("Third paragraph.", "Chinese", prompt, "test/model")); !ok {
+		t.Error("Expected cache entry keyed on the context prompt")
+	}
+	if _, ok := cache.Get(CacheKey("Third paragraph.", "Chinese", "", "test/model")); ok {
+		t.Error("Expected no cache entry without context")
+	}
+}
+
+// contextReplyClient 按上下文中的前一段给出不同的译文，其余文本返回 pseudoTranslate(原文)
+type contextReplyClient struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (c *contextReplyClient) Translate(text, targetLanguage, userPrompt string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.calls == nil {
+		c.calls = make(map[string]int)
+	}
+	c.calls[text]++
+	if text != "Yes." {
+		return pseudoTranslate(text), nil
+	}
+	if strings.Contains(userPrompt, "<source>Do you like spring?</source>") {
+		return "喜欢。", nil
+	}
+	return "是的。", nil
+}
+
+func TestTranslateEPUB_SameTextInDifferentContexts(t *testing.T) {
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  testOPF,
+		"OEBPS/text/a.xhtml": testChapter("Do you like spring?", "Yes.", "Is it raining?", "Yes."),
+		"OEBPS/text/b.xhtml": testChapter("Is it raining?", "Yes."),
+	})
+
+	client := &contextReplyClient{}
+	dt := &DocumentTranslator{
+		Client:   client,
+		Cache:    newTestCache(t),
+		Provider: "test/model",
+		Options:  TranslateOptions{ContextParagraphs: 1},
+	}
+
+	output := filepath.Join(t.TempDir(), "out.epub")
+	if _, err := dt.TranslateEPUB("same", path, output, "Chinese", "", "monolingual", nil, nil); err != nil {
+		t.Fatalf("TranslateEPUB failed: %v", err)
+	}
+
+	// 上下文不同的相同原文各自翻译，上下文也相同的只请求一次
+	if client.calls["Yes."] != 2 {
+		t.Errorf("Expected one request per distinct context, got %d", client.calls["Yes."])
+	}
+	out, err := OpenEPUB(output)
+	if err != nil {
+		t.Fatalf("OpenEPUB failed: %v", err)
+	}
+	got := string(out.Files["OEBPS/text/a.xhtml"])
+	if !strings.Contains(got, "<p>喜欢。</p>") || strings.Count(got, "<p>是的。</p>") != 1 {
+		t.Errorf("Expected each reply translated in its own context, got %s", got)
+	}
+	if got := string(out.Files["OEBPS/text/b.xhtml"]); !strings.Contains(got, "<p>是的。</p>") {
+		t.Errorf("Expected the shared translation for the repeated context, got %s", got)
+	}
+}
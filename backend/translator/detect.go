@@ -0,0 +1,215 @@
+package translator
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// minDetectShare 主要文字在字母中所占的最低比例（CJK 字符计 4），低于该值视为混合文本，不判断语言
+	minDetectShare = 0.6
+	// minDetectWords、minDetectHits 拉丁文字按常用词判断语言时的最少词数和最少命中数
+	minDetectWords = 5
+	minDetectHits  = 2
+)
+
+// scriptLanguages 只由一种语言（或其主要语言）使用的文字
+var scriptLanguages = []struct {
+	script *unicode.RangeTable
+	lang   string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+}
+
+// commonWords 拉丁文字语言的常用功能词，按词命中数区分语言
+var commonWords = map[string]map[string]bool{
+	"en": wordSet("the of and to in is it you that he was for on are with as his they be at have this from or had by not but what all were we when your can said there an which she do their if will would been has who its"),
+	"fr": wordSet("le la les de des du un une et est en que qui dans pour pas sur au aux avec il elle ne se ce son sa ses mais ou nous vous ils plus par été être avait comme tout fait aussi bien sans leur même ces cette où lui je on"),
+	"de": wordSet("der die das und ist nicht ein eine zu den von mit sich des auf für im dem es auch als an er sie wird bei aus wie noch nach einer um am sind war oder ich wir was hat aber nur vor zur bis mehr durch über man kann haben"),
+	"es": wordSet("el la los las de del que en y se por un una para con no es al lo como más pero sus le ya o este sí porque esta entre cuando muy sin sobre también me hasta hay donde quien desde todo nos durante todos uno les ni ellos"),
+	"it": wordSet("il lo la gli le di e che per un una in del non è sono con si da al della dei come ma anche più nel alla questo ha ci sua suo loro quando molto essere stato tutto fra ancora io mi ne sul cosa"),
+	"pt": wordSet("o a os as de que e do da em um uma para com não no se na por mais dos como mas ao ele das à seu sua ou quando muito nos já eu também só pelo pela até isso ela entre depois sem mesmo aos seus quem nas"),
+	"nl": wordSet("de het een en van in is dat op te zijn met voor niet aan er om ook als bij maar door nog dan hij ze wat uit naar kan wordt werd over deze hun tot al was heeft zich haar geen ik wij"),
+}
+
+// ukrainianLetters、persianLetters 区分同一文字的不同语言
+const (
+	ukrainianLetters = "іїєґІЇЄҐ"
+	persianLetters   = "پچژگ"
+)
+
+// simplifiedHan、traditionalHan 常用字的简体和繁体写法，用于区分简繁中文
+const (
+	simplifiedHan  = "这们说个来时会为国对过还没开关么问见长门马书东车让将与从当发经现写网络编应学语话页译读电后样无实题边间头买卖"
+	traditionalHan = "這們說個來時會為國對過還沒開關麼問見長門馬書東車讓將與從當發經現寫網絡編應學語話頁譯讀電後樣無實題邊間頭買賣"
+)
+
+// languageNames 语言代码对应的英文名称，用于提示词
+var languageNames = map[string]string{
+	"zh": "Chinese", "zh-cn": "Simplified Chinese", "zh-tw": "Traditional Chinese",
+	"ja": "Japanese", "ko": "Korean", "ru": "Russian", "uk": "Ukrainian",
+	"ar": "Arabic", "fa": "Persian", "he": "Hebrew", "el": "Greek", "th": "Thai", "hi": "Hindi",
+	"en": "English", "fr": "French", "de": "German", "es": "Spanish", "it": "Italian", "pt": "Portuguese", "nl": "Dutch",
+}
+
+// wordSet 把空格分隔的词转为集合
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+// DetectLanguage 判断文本的语言，返回语言代码；文本过短、混合多种文字或无法判断时返回空
+// 先按文字判断（汉字、假名、谚文、西里尔字母等），拉丁文字再按常用词命中数区分语言
+func DetectLanguage(text string) string {
+	counts := make(map[*unicode.RangeTable]int)
+	total := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		script := letterScript(r)
+		weight := 1
+		if isCJK(r) {
+			weight = 4
+		}
+		counts[script] += weight
+		total += weight
+	}
+	if total == 0 {
+		return ""
+	}
+
+	// 汉字和假名都计入日文，日文文本中假名通常占一半左右
+	cjk := counts[unicode.Han] + counts[unicode.Hiragana] + counts[unicode.Katakana]
+	switch {
+	case float64(cjk) >= minDetectShare*float64(total):
+		if kana := counts[unicode.Hiragana] + counts[unicode.Katakana]; kana*10 >= cjk {
+			return "ja"
+		}
+		return chineseVariant(text)
+	case float64(counts[unicode.Latin]) >= minDetectShare*float64(total):
+		return detectLatin(text)
+	}
+	for _, sl := range scriptLanguages {
+		if float64(counts[sl.script]) < minDetectShare*float64(total) {
+			continue
+		}
+		switch {
+		case sl.lang == "ru" && strings.ContainsAny(text, ukrainianLetters):
+			return "uk"
+		case sl.lang == "ar" && strings.ContainsAny(text, persianLetters):
+			return "fa"
+		}
+		return sl.lang
+	}
+	return ""
+}
+
+// letterScript 返回字母所属的文字，未收录的文字返回 nil
+func letterScript(r rune) *unicode.RangeTable {
+	for _, script := range []*unicode.RangeTable{unicode.Latin, unicode.Han, unicode.Hiragana, unicode.Katakana} {
+		if unicode.Is(script, r) {
+			return script
+		}
+	}
+	for _, sl := range scriptLanguages {
+		if unicode.Is(sl.script, r) {
+			return sl.script
+		}
+	}
+	return nil
+}
+
+// chineseVariant 根据简繁常用字区分简体（zh-cn）和繁体（zh-tw），无法区分时返回 zh
+func chineseVariant(text string) string {
+	simplified, traditional := 0, 0
+	for _, r := range text {
+		if strings.ContainsRune(simplifiedHan, r) {
+			simplified++
+		} else if strings.ContainsRune(traditionalHan, r) {
+			traditional++
+		}
+	}
+	switch {
+	case simplified > traditional:
+		return "zh-cn"
+	case traditional > simplified:
+		return "zh-tw"
+	}
+	return "zh"
+}
+
+// detectLatin 按常用词命中数判断拉丁文字的语言，命中太少或最多的两种语言持平时返回空
+func detectLatin(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	if len(words) < minDetectWords {
+		return ""
+	}
+
+	hits := make(map[string]int)
+	for _, word := range words {
+		for lang, set := range commonWords {
+			if set[word] {
+				hits[lang]++
+			}
+		}
+	}
+	langs := make([]string, 0, len(hits))
+	for lang := range hits {
+		langs = append(langs, lang)
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if hits[langs[i]] != hits[langs[j]] {
+			return hits[langs[i]] > hits[langs[j]]
+		}
+		return langs[i] < langs[j]
+	})
+	if len(langs) == 0 || hits[langs[0]] < minDetectHits || (len(langs) > 1 && hits[langs[1]] == hits[langs[0]]) {
+		return ""
+	}
+	return langs[0]
+}
+
+// dominantLanguage 返回按字符数加权后占比最高的主语言，languages 为文本 -> 语言
+func dominantLanguage(languages map[string]string) string {
+	weights := make(map[string]int)
+	for text, lang := range languages {
+		if lang == "" {
+			continue
+		}
+		primary, _, _ := strings.Cut(lang, "-")
+		weights[primary] += len([]rune(text))
+	}
+	best := ""
+	for lang, weight := range weights {
+		if best == "" || weight > weights[best] || (weight == weights[best] && lang < best) {
+			best = lang
+		}
+	}
+	return best
+}
+
+// languageName 返回语言代码对应的英文名称，未收录的返回原值
+func languageName(lang string) string {
+	if name, ok := languageNames[NormalizeLanguage(lang)]; ok {
+		return name
+	}
+	return lang
+}
+
+// inLanguage 判断检测到的语言 detected 是否就是 lang（语言名称或代码）
+func inLanguage(detected, lang string) bool {
+	return detected != "" && sameLanguage(detected, NormalizeLanguage(lang))
+}
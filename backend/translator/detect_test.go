@@ -0,0 +1,96 @@
+package translator
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"这是一段已经翻译好的中文，我们不需要再翻译它。", "zh-cn"},
+		{"這是一段已經翻譯好的中文，我們不需要再翻譯它。", "zh-tw"},
+		{"天地玄黄", "zh"},
+		{"これは日本語の文章です。", "ja"},
+		{"이것은 한국어 문장입니다.", "ko"},
+		{"Это предложение написано на русском языке.", "ru"},
+		{"He said that the ship was late again.", "en"},
+		{"Il a dit que le bateau était encore en retard.", "fr"},
+		{"Er sagte, dass das Schiff wieder zu spät war und nicht kam.", "de"},
+		{"Dijo que el barco llegó tarde otra vez por la tormenta.", "es"},
+		// 太短或没有常用词时无法判断
+		{"Chapter One", ""},
+		{"Frodo Baggins", ""},
+		{"12345", ""},
+		// 中英混合且中文占多数时按中文
+		{"使用 Python 编写网络应用程序", "zh-cn"},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.text); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestInLanguage(t *testing.T) {
+	tests := []struct {
+		detected, target string
+		want             bool
+	}{
+		{"zh-cn", "Chinese", true},
+		{"zh-cn", "简体中文", true},
+		{"zh-cn", "Traditional Chinese", false},
+		{"zh", "Traditional Chinese", true},
+		{"en", "English", true},
+		{"en", "Chinese", false},
+		{"", "Chinese", false},
+	}
+	for _, tt := range tests {
+		if got := inLanguage(tt.detected, tt.target); got != tt.want {
+			t.Errorf("inLanguage(%q, %q) = %v, want %v", tt.detected, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestTranslateEPUB_SkipsTargetLanguage(t *testing.T) {
+	chinese := "这是一段已经是中文的文字，不需要翻译。"
+	english := "He said that the ship was late again."
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  strings.Replace(testOPF, "<dc:language>en</dc:language>", "<dc:language>zh</dc:language>", 1),
+		"OEBPS/text/a.xhtml": testChapter(english),
+		"OEBPS/text/b.xhtml": testChapter(chinese),
+	})
+
+	client := &recordingClient{}
+	dt := &DocumentTranslator{Client: client, Cache: newTestCache(t), Provider: "test/model"}
+
+	output := filepath.Join(t.TempDir(), "out.epub")
+	if _, err := dt.TranslateEPUB("detect", path, output, "Chinese", "", "bilingual", nil, nil); err != nil {
+		t.Fatalf("TranslateEPUB failed: %v", err)
+	}
+
+	if _, ok := client.prompts[chinese]; ok {
+		t.Error("Expected the Chinese block not to be sent for translation")
+	}
+	if got := client.prompts[english]; got != "The source text is in English." {
+		t.Errorf("Expected the detected source language in the prompt, got %q", got)
+	}
+	// 检测结果优先于元数据中的语言
+	if got := dt.SourceLanguage(); got != "en" {
+		t.Errorf("Expected source language en, got %q", got)
+	}
+	if got := dt.SkippedBlocks(); got != 1 {
+		t.Errorf("Expected 1 skipped block, got %d", got)
+	}
+
+	out, err := OpenEPUB(output)
+	if err != nil {
+		t.Fatalf("OpenEPUB failed: %v", err)
+	}
+	if content := string(out.Files["OEBPS/text/b.xhtml"]); strings.Count(content, chinese) != 1 {
+		t.Errorf("Expected the Chinese block to be kept once, got %s", content)
+	}
+}
@@ -14,11 +14,11 @@ const (
 	DocumentTypePDF  DocumentType = "pdf"
 )
 
-// Document 文档接口
+// Document 文档接口，译文按文本块 ID（Segment.ID）插入
 type Document interface {
-	GetTextBlocks() []string
-	InsertTranslation(translations map[string]string) error
-	InsertMonolingualTranslation(translations map[string]string) error
+	GetSegments() []Segment
+	InsertTranslation(translations map[SegmentID]string) error
+	InsertMonolingualTranslation(translations map[SegmentID]string) error
 	Save(outputPath string) error
 }
 
@@ -0,0 +1,463 @@
+package translator
+
+import (
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// blockTranslationStyle、inlineTranslationStyle 双语模式下译文的样式
+const (
+	blockTranslationStyle  = "color: #666; font-style: italic; margin-top: 0.5em;"
+	inlineTranslationStyle = "color: #666; font-style: italic;"
+)
+
+// skippedElements 内容不是正文的元素，不提取文本；rt、rp 是 ruby 的注音
+var skippedElements = map[string]bool{
+	"script": true, "style": true, "template": true, "noscript": true, "rt": true, "rp": true,
+}
+
+// inlineElements 文本块内的内联元素，编码为占位符；其他元素（表格、列表、section、figure、pre 等）都是文本块的边界
+var inlineElements = map[string]bool{
+	"a": true, "abbr": true, "b": true, "bdi": true, "bdo": true, "big": true, "br": true, "cite": true,
+	"code": true, "data": true, "del": true, "dfn": true, "em": true, "font": true, "i": true, "img": true,
+	"ins": true, "kbd": true, "mark": true, "q": true, "rp": true, "rt": true, "ruby": true, "s": true,
+	"samp": true, "small": true, "span": true, "strike": true, "strong": true, "sub": true, "sup": true,
+	"time": true, "tt": true, "u": true, "var": true, "wbr": true,
+}
+
+// segmentElements 单独成块的内联元素：span、b（词典中通常是词条）和 font（通常是例句）
+var segmentElements = map[string]bool{
+	"span": true, "b": true, "font": true,
+}
+
+// textSegment 文本块：同一元素内连续的文本节点，提取和插入共用同一种划分
+type textSegment struct {
+	// id 文本块在 body 中的位置，见 segmentID
+	id string
+	// text 文本块的原文，包含内联元素时内联元素编码为占位符，如 "See <x1>the note</x1>.<x2/>"
+	text  string
+	nodes []*html.Node
+	// container 文本块所在的块级元素或 span/b/font
+	container *html.Node
+	// first 文本块的第一个节点，before 文本块之后紧跟的子块（都是 container 的直接子节点），
+	// before 为空表示文本块延续到 container 末尾
+	first, before *html.Node
+	// inline 编码为占位符的内联元素，inline[i] 对应 x(i+1)；为空表示 text 是纯文本
+	inline []*html.Node
+}
+
+// bodyTree body 内容解析得到的节点树，记录每个节点的原始字节
+type bodyTree struct {
+	root *html.Node
+	raw  map[*html.Node]rawToken
+}
+
+// rawToken 节点在原文中的字节：元素的开始标签和结束标签（隐式结束时为空），其余节点的完整内容
+// data 是解析时的文本，文本节点被修改后按新内容重新生成
+type rawToken struct {
+	start, end string
+	data       string
+}
+
+// pClosers 开始时隐式结束 <p> 的元素（HTML5 规范中的 "close a p element"）
+var pClosers = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "center": true, "details": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "fieldset": true, "figcaption": true, "figure": true,
+	"footer": true, "form": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hgroup": true, "hr": true, "li": true, "main": true, "menu": true, "nav": true,
+	"ol": true, "p": true, "pre": true, "section": true, "summary": true, "table": true, "ul": true,
+}
+
+// scopeBoundaries 查找隐式结束的元素时不越过这些元素
+var scopeBoundaries = map[string]bool{
+	"table": true, "td": true, "th": true, "caption": true, "button": true, "object": true,
+	"svg": true, "math": true, "template": true,
+}
+
+// parseBody 用 HTML5 分词器把 body 内容解析为 body 元素下的节点树
+// 按 HTML5 的规则处理未闭合的 <p>、<li> 和多余的结束标签，与浏览器看到的结构一致；
+// 与 html.Parse 不同的是保留 XHTML 的自闭合写法 <a id="x"/>，并记录原始字节，序列化时未修改的部分原样输出
+func parseBody(content string) (*bodyTree, error) {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	tree := &bodyTree{root: body, raw: make(map[*html.Node]rawToken)}
+	stack := []*html.Node{body}
+	top := func() *html.Node { return stack[len(stack)-1] }
+	// popTo 弹出到 stack[i]（不含），被弹出的元素没有结束标签
+	popTo := func(i int) { stack = stack[:i] }
+	// lookup 在作用域内查找最近的打开元素，找不到返回 -1
+	lookup := func(names map[string]bool, stops map[string]bool) int {
+		for i := len(stack) - 1; i > 0; i-- {
+			if names[stack[i].Data] {
+				return i
+			}
+			if scopeBoundaries[stack[i].Data] || stops[stack[i].Data] {
+				break
+			}
+		}
+		return -1
+	}
+
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if err := z.Err(); err != io.EOF {
+				return nil, err
+			}
+			return tree, nil
+		}
+		// Token 会把标签名改为小写，先保存原始字节
+		raw := string(z.Raw())
+		tok := z.Token()
+
+		switch tt {
+		case html.TextToken:
+			n := &html.Node{Type: html.TextNode, Data: tok.Data}
+			top().AppendChild(n)
+			tree.raw[n] = rawToken{start: raw, data: tok.Data}
+		case html.CommentToken, html.DoctypeToken:
+			// 注释、<?xml-stylesheet?> 等处理指令和 CDATA 都作为注释原样保留
+			n := &html.Node{Type: html.CommentNode, Data: tok.Data}
+			if tt == html.DoctypeToken {
+				n.Type = html.DoctypeNode
+			}
+			top().AppendChild(n)
+			tree.raw[n] = rawToken{start: raw}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if tt == html.SelfClosingTagToken {
+				// XHTML 自闭合的 <script/>、<style/>、<title/> 等没有内容，不能让分词器把后面的内容当作原始文本
+				z.NextIsNotRawText()
+			}
+			if pClosers[tok.Data] {
+				if i := lookup(map[string]bool{"p": true}, nil); i > 0 {
+					popTo(i)
+				}
+			}
+			switch tok.Data {
+			case "li":
+				if i := lookup(map[string]bool{"li": true}, map[string]bool{"ul": true, "ol": true}); i > 0 {
+					popTo(i)
+				}
+			case "dd", "dt":
+				if i := lookup(map[string]bool{"dd": true, "dt": true}, map[string]bool{"dl": true}); i > 0 {
+					popTo(i)
+				}
+			}
+			n := &html.Node{Type: html.ElementNode, Data: tok.Data, DataAtom: tok.DataAtom, Attr: tok.Attr}
+			top().AppendChild(n)
+			tree.raw[n] = rawToken{start: raw}
+			if tt == html.StartTagToken && !isVoidElement(tok.Data) {
+				stack = append(stack, n)
+			}
+		case html.EndTagToken:
+			i := len(stack) - 1
+			for i > 0 && stack[i].Data != tok.Data {
+				i--
+			}
+			if i == 0 {
+				// 没有对应开始标签的结束标签，原样保留
+				n := &html.Node{Type: html.RawNode, Data: raw}
+				top().AppendChild(n)
+				tree.raw[n] = rawToken{start: raw}
+				continue
+			}
+			rt := tree.raw[stack[i]]
+			rt.end = raw
+			tree.raw[stack[i]] = rt
+			popTo(i)
+		}
+	}
+}
+
+// isVoidElement 判断是否为没有内容的 HTML 元素
+func isVoidElement(tag string) bool {
+	switch tag {
+	case "area", "base", "br", "col", "embed", "hr", "img", "input", "link", "meta", "param", "source", "track", "wbr":
+		return true
+	}
+	return false
+}
+
+// render 序列化 body 元素的内容：解析得到且未修改的节点输出原始字节，新增的节点用 html.Render 生成
+func (t *bodyTree) render() (string, error) {
+	var buf strings.Builder
+	for n := t.root.FirstChild; n != nil; n = n.NextSibling {
+		if err := t.renderNode(&buf, n); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+// renderNode 序列化一个节点及其子节点
+func (t *bodyTree) renderNode(buf *strings.Builder, n *html.Node) error {
+	rt, ok := t.raw[n]
+	if !ok {
+		return html.Render(buf, n)
+	}
+
+	switch n.Type {
+	case html.TextNode:
+		if n.Data != rt.data {
+			buf.WriteString(html.EscapeString(n.Data))
+			return nil
+		}
+		buf.WriteString(rt.start)
+	case html.ElementNode:
+		buf.WriteString(rt.start)
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if err := t.renderNode(buf, c); err != nil {
+				return err
+			}
+		}
+		buf.WriteString(rt.end)
+	default:
+		buf.WriteString(rt.start)
+	}
+	return nil
+}
+
+// collectSegments 按块级元素和 span/b/font 划分 body 中的文本块
+func collectSegments(body *html.Node) []textSegment {
+	s := &segmenter{}
+	for c := body.FirstChild; c != nil; c = c.NextSibling {
+		s.walk(c, body, c)
+	}
+	s.flush(body, nil)
+
+	// 在修改节点树之前确定 ID
+	counts := make(map[*html.Node]int)
+	for i := range s.segments {
+		container := s.segments[i].container
+		s.segments[i].id = segmentID(container, counts[container])
+		counts[container]++
+	}
+	return s.segments
+}
+
+// segmentID 文本块在 body 中的位置：所在元素的路径（各级元素在父元素的子元素中的序号，用 . 连接）和元素内的序号
+// 只计元素不计文本节点，空白的变化不影响 ID
+func segmentID(container *html.Node, index int) string {
+	var path []string
+	for n := container; n.Parent != nil; n = n.Parent {
+		i := 0
+		for c := n.PrevSibling; c != nil; c = c.PrevSibling {
+			if c.Type == html.ElementNode {
+				i++
+			}
+		}
+		path = append(path, strconv.Itoa(i))
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return strings.Join(path, ".") + ":" + strconv.Itoa(index)
+}
+
+// segmenter 遍历节点树时收集当前文本块
+type segmenter struct {
+	segments []textSegment
+	// text 纯文本，markup 内联元素编码为占位符的文本
+	text   strings.Builder
+	markup strings.Builder
+	nodes  []*html.Node
+	first  *html.Node
+	inline []*html.Node
+	// open 当前文本块中未结束的成对占位符数，flushes 已结束的文本块数
+	open    int
+	flushes int
+	// plain 内联元素跨越了文本块边界（如 <em> 中有块级元素），当前文本块只能按纯文本处理
+	plain bool
+}
+
+// walk 遍历节点；top 是 n 在 container 下的直接子节点
+func (s *segmenter) walk(n, container, top *html.Node) {
+	if n.Type == html.ElementNode && (isBlockElement(n.Data) || segmentElements[n.Data]) {
+		// 前面的文本在该元素之前结束；脚本、样式等块中没有正文
+		s.flush(container, top)
+		if skippedElements[n.Data] {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			s.walk(c, n, c)
+		}
+		s.flush(n, nil)
+		return
+	}
+
+	if s.first == nil {
+		s.first = top
+	}
+	switch n.Type {
+	case html.TextNode:
+		// 文本节点按原样拼接，"Hello, <em>world</em>!" 提取为 "Hello, <x1>world</x1>!"
+		s.text.WriteString(n.Data)
+		s.markup.WriteString(n.Data)
+		if strings.TrimSpace(n.Data) != "" {
+			s.nodes = append(s.nodes, n)
+		}
+		return
+	case html.ElementNode:
+		if n.Data == "br" {
+			s.text.WriteString(" ")
+		}
+	default:
+		// 注释和多余的结束标签不发给模型，单语模式下还原内联元素时去掉
+		return
+	}
+
+	// 元素编码为占位符：有内容的为成对的 <xN>…</xN>，没有内容的（<br/>、<img/>、空锚点）和不翻译的元素为 <xN/>
+	s.inline = append(s.inline, n)
+	tag := "x" + strconv.Itoa(len(s.inline))
+	if emptyPlaceholder(n) {
+		s.markup.WriteString("<" + tag + "/>")
+		return
+	}
+
+	s.markup.WriteString("<" + tag + ">")
+	s.open++
+	flushes := s.flushes
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		s.walk(c, container, top)
+	}
+	if s.flushes != flushes {
+		// 元素的开始标签在之前的文本块中
+		s.plain = true
+	} else {
+		s.open--
+	}
+	s.markup.WriteString("</" + tag + ">")
+}
+
+// flush 结束当前文本块
+func (s *segmenter) flush(container, before *html.Node) {
+	text := cleanText(s.text.String())
+	if len(s.nodes) > 0 && shouldExtractText(text) {
+		seg := textSegment{text: text, nodes: s.nodes, container: container, first: s.first, before: before}
+		if len(s.inline) > 0 && !s.plain && s.open == 0 {
+			seg.text = cleanText(s.markup.String())
+			seg.inline = s.inline
+		}
+		s.segments = append(s.segments, seg)
+	}
+	s.text.Reset()
+	s.markup.Reset()
+	s.nodes = nil
+	s.first = nil
+	s.inline = nil
+	s.open = 0
+	s.plain = false
+	s.flushes++
+}
+
+// insertBilingual 在原文之后插入译文：span/b 中的文本追加方括号中的 span，其余追加 div
+// 文本块延续到块级元素末尾时译文放在元素内的最后，span/b/font 的译文放在元素之后
+func (seg textSegment) insertBilingual(translation string) {
+	content := []*html.Node{{Type: html.TextNode, Data: translation}}
+	if len(seg.inline) > 0 {
+		if nodes, ok := seg.restoreInline(translation, true); ok {
+			content = nodes
+		} else {
+			log.Printf("译文中的占位符与原文不一致，按纯文本插入: %s", translation)
+			content[0].Data = stripPlaceholders(translation)
+		}
+	}
+
+	inline := seg.container.Data == "span" || seg.container.Data == "b"
+	var node *html.Node
+	if inline {
+		content = append([]*html.Node{{Type: html.TextNode, Data: " ["}}, content...)
+		content = append(content, &html.Node{Type: html.TextNode, Data: "]"})
+		node = translationNode("span", inlineTranslationStyle, content...)
+	} else {
+		node = translationNode("div", blockTranslationStyle, content...)
+	}
+
+	switch {
+	case seg.before != nil:
+		seg.container.InsertBefore(node, seg.before)
+	case inline || seg.container.Data == "font":
+		seg.container.Parent.InsertBefore(node, seg.container.NextSibling)
+	default:
+		seg.container.AppendChild(node)
+	}
+}
+
+// replaceText 用译文替换原文：包含内联元素时把译文还原到原来的元素上；
+// 否则（或译文中的占位符与原文不一致时）译文写入第一个文本节点，其余文本节点删除，保留首尾空白
+func (seg textSegment) replaceText(translation string) {
+	if len(seg.inline) > 0 {
+		if seg.replaceInline(translation) {
+			return
+		}
+		log.Printf("译文中的占位符与原文不一致，按纯文本插入: %s", translation)
+		translation = stripPlaceholders(translation)
+	}
+
+	first, last := seg.nodes[0], seg.nodes[len(seg.nodes)-1]
+	leading := first.Data[:len(first.Data)-len(strings.TrimLeft(first.Data, " \t\r\n"))]
+	trailing := last.Data[len(strings.TrimRight(last.Data, " \t\r\n")):]
+	first.Data = leading + translation + trailing
+	for _, n := range seg.nodes[1:] {
+		n.Parent.RemoveChild(n)
+	}
+}
+
+// replaceInline 用还原了内联元素的译文替换文本块的全部节点（从 first 到 before），保留首尾空白
+func (seg textSegment) replaceInline(translation string) bool {
+	var old []*html.Node
+	for n := seg.first; n != nil && n != seg.before; n = n.NextSibling {
+		old = append(old, n)
+	}
+	var leading, trailing string
+	if first := old[0]; first.Type == html.TextNode {
+		leading = first.Data[:len(first.Data)-len(strings.TrimLeft(first.Data, " \t\r\n"))]
+	}
+	if last := old[len(old)-1]; last.Type == html.TextNode {
+		trailing = last.Data[len(strings.TrimRight(last.Data, " \t\r\n")):]
+	}
+
+	// 先取出原元素再删除旧节点，原元素可能就是 container 的直接子节点
+	nodes, ok := seg.restoreInline(translation, false)
+	if !ok {
+		return false
+	}
+	for _, n := range old {
+		if n.Parent == seg.container {
+			seg.container.RemoveChild(n)
+		}
+	}
+
+	if leading != "" {
+		nodes = append([]*html.Node{{Type: html.TextNode, Data: leading}}, nodes...)
+	}
+	if trailing != "" {
+		nodes = append(nodes, &html.Node{Type: html.TextNode, Data: trailing})
+	}
+	for _, n := range nodes {
+		seg.container.InsertBefore(n, seg.before)
+	}
+	return true
+}
+
+// translationNode 创建包含译文的元素
+func translationNode(tag, style string, content ...*html.Node) *html.Node {
+	n := &html.Node{
+		Type:     html.ElementNode,
+		Data:     tag,
+		DataAtom: atom.Lookup([]byte(tag)),
+		Attr: []html.Attribute{
+			{Key: "class", Val: "translation"},
+			{Key: "style", Val: style},
+		},
+	}
+	for _, c := range content {
+		n.AppendChild(c)
+	}
+	return n
+}
@@ -0,0 +1,201 @@
+package translator
+
+import (
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// roundTripBodies 解析后原样序列化应与输入逐字节相同的 body 内容
+var roundTripBodies = map[string]string{
+	"namespaces": `<section epub:type="chapter" xml:lang="en"><h1 epub:type="title">One</h1></section>`,
+	"void":       `<p>Line<br/>break <img src="a.png" alt="A &amp; B"/> and <br /> spaced</p><hr/>`,
+	"empty":      `<p><a id="note1"/>Anchor <span class="x"/>kept.</p>`,
+	"entities":   `<p>&#8220;Quoted&#8221; &amp; spaced&#160;text &lt;tag&gt; &#x2014; done</p>`,
+	"quotes":     `<p class='single' data-x=unquoted title="a &quot;b&quot;">Attrs</p>`,
+	"comments":   "<!-- a comment --><p>Text<!--inline--> more</p>\n<?pagebreak id=\"3\"?>",
+	"cdata":      `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10"><![CDATA[x < y]]><linearGradient id="g"/></svg>`,
+	"script":     `<script type="text/javascript">if (a < b && c) { x = "</p>"; }</script><style>p > em { color: red; }</style>`,
+	"sloppy":     "<P CLASS=Intro>Unclosed\n<p>Stray</div> end\n<ul><li>One<li>Two</ul>",
+	"crlf":       "<p>Windows\r\nline endings</p>\r\n<p>Second</p>\r\n",
+	"whitespace": "\n  <div>\n    <p>  Indented  <em> text </em>  </p>\n  </div>\n",
+}
+
+// insertBody 按原文给出译文，插入 body 内容
+func insertBody(body string, translations map[string]string, apply func(textSegment, string)) string {
+	tree, err := parseBody(body)
+	if err != nil {
+		return body
+	}
+	byID := make(map[SegmentID]string)
+	for _, seg := range collectSegments(tree.root) {
+		if translated, ok := translations[seg.text]; ok {
+			byID[SegmentID("#"+seg.id)] = translated
+		}
+	}
+	return rewriteSegments("", body, byID, apply)
+}
+
+func TestBodyTree_RoundTrip(t *testing.T) {
+	for name, body := range roundTripBodies {
+		tree, err := parseBody(body)
+		if err != nil {
+			t.Errorf("%s: parseBody failed: %v", name, err)
+			continue
+		}
+		got, err := tree.render()
+		if err != nil {
+			t.Errorf("%s: render failed: %v", name, err)
+			continue
+		}
+		if got != body {
+			t.Errorf("%s: round trip changed the content\n got: %q\nwant: %q", name, got, body)
+		}
+		if got := insertBody(body, map[string]string{}, textSegment.insertBilingual); got != body {
+			t.Errorf("%s: InsertTranslation with no translations changed the content: %q", name, got)
+		}
+		if got := insertBody(body, map[string]string{}, textSegment.replaceText); got != body {
+			t.Errorf("%s: InsertMonolingualTranslation with no translations changed the content: %q", name, got)
+		}
+	}
+}
+
+func TestBodyTree_Structure(t *testing.T) {
+	// 未闭合的 <p>、<li> 和自闭合的 <a/> 按浏览器的方式划分文本块
+	got := ExtractTextBlocks(roundTripBodies["sloppy"] + roundTripBodies["empty"])
+	want := []string{"Unclosed", "Stray end", "One", "Two", "<x1/>Anchor", "kept."}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestBodyTree_TablesAndLists(t *testing.T) {
+	// 表格、列表、figure、pre 等不在内联元素白名单中的元素是文本块的边界，不编码为占位符
+	body := `<section><table><tr><th>Name</th><td>Second <em>cell</em> text.</td></tr></table>
+<ul><li>One item.</li><li>Two <a href="#n1">items</a>.</li></ul>
+<dl><dt>Term</dt><dd>Definition text.</dd></dl>
+<figure><img src="a.png"/><figcaption>A caption.</figcaption></figure>
+<pre>code block</pre><script>var x = "not text";</script></section>`
+	got := ExtractTextBlocks(body)
+	want := []string{"Name", "Second <x1>cell</x1> text.", "One item.", "Two <x1>items</x1>.", "Term", "Definition text.", "A caption.", "code block"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	translated := insertBody(body, map[string]string{
+		"Name":                       "名称",
+		"Second <x1>cell</x1> text.": "第二个<x1>单元格</x1>。",
+		"Two <x1>items</x1>.":        "两个<x1>条目</x1>。",
+		"Definition text.":           "定义。",
+	}, textSegment.replaceText)
+	for _, want := range []string{"<tr><th>名称</th><td>第二个<em>单元格</em>。</td></tr>", `<li>One item.</li><li>两个<a href="#n1">条目</a>。</li>`, "<dt>Term</dt><dd>定义。</dd>"} {
+		if !strings.Contains(translated, want) {
+			t.Errorf("Expected %q in output, got %s", want, translated)
+		}
+	}
+}
+
+func TestInsertTranslation_PreservesUnchangedBytes(t *testing.T) {
+	body := `<section epub:type="chapter" xml:lang="en">
+<p class='first'>Keep &#8220;this&#8221;<br/>as it is.</p>
+<p>Translate <em>me</em>.</p>
+<!-- end -->
+</section>`
+	translations := map[string]string{"Translate <x1>me</x1>.": "翻译 \"<x1>我</x1>\" & <你>"}
+
+	got := insertBody(body, translations, textSegment.insertBilingual)
+	want := strings.Replace(body, "<em>me</em>.</p>", `<em>me</em>.<div class="translation" style="color: #666; font-style: italic; margin-top: 0.5em;">翻译 &#34;<em>我</em>&#34; &amp; &lt;你&gt;</div></p>`, 1)
+	if got != want {
+		t.Errorf("Unexpected bilingual output\n got: %s\nwant: %s", got, want)
+	}
+
+	got = insertBody(body, translations, textSegment.replaceText)
+	want = strings.Replace(body, "Translate <em>me</em>.", "翻译 &#34;<em>我</em>&#34; &amp; &lt;你&gt;", 1)
+	if got != want {
+		t.Errorf("Unexpected monolingual output\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestEPUBFile_InsertTranslation_WellFormed(t *testing.T) {
+	chapter := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<?xml-stylesheet type="text/css" href="style.css"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="en">
+<head><title>Chapter</title><link rel="stylesheet" href="style.css"/></head>
+<body epub:type="bodymatter">
+<!-- chapter start -->
+<section epub:type="chapter"><h1>Chapter One</h1>
+<p>Fish &amp; chips<br/>were served.<a id="n1" epub:type="noteref" href="#fn1"><sup>1</sup></a></p>
+<img src="plate.png" alt="Fish &amp; chips"/>
+</section>
+</body>
+</html>`
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  testOPF,
+		"OEBPS/text/a.xhtml": chapter,
+	})
+
+	for _, mode := range []string{"bilingual", "monolingual"} {
+		epub, err := OpenEPUB(path)
+		if err != nil {
+			t.Fatalf("OpenEPUB failed: %v", err)
+		}
+		byText := map[string]string{"Chapter One": "第一章", "Fish & chips<x1/>were served.<x2><x3>1</x3></x2>": "端上了炸鱼 & 薯条<x1/>。<x2><x3>1</x3></x2>"}
+		translations := make(map[SegmentID]string)
+		for _, seg := range epub.GetSegments() {
+			if translated, ok := byText[seg.Text]; ok {
+				translations[seg.ID] = translated
+			}
+		}
+		if mode == "bilingual" {
+			err = epub.InsertTranslation(translations)
+		} else {
+			err = epub.InsertMonolingualTranslation(translations)
+		}
+		if err != nil {
+			t.Fatalf("%s: insert failed: %v", mode, err)
+		}
+		out := string(epub.Files["OEBPS/text/a.xhtml"])
+
+		head := chapter[:strings.Index(chapter, "<section")]
+		if !strings.HasPrefix(out, head) || !strings.HasSuffix(out, "</section>\n</body>\n</html>") {
+			t.Errorf("%s: expected everything outside the changed blocks kept, got %s", mode, out)
+		}
+		for _, want := range []string{"第一章", "端上了炸鱼 &amp; 薯条<br/>。", `<img src="plate.png" alt="Fish &amp; chips"/>`, `<a id="n1" epub:type="noteref" href="#fn1">`} {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s: expected %q in output, got %s", mode, want, out)
+			}
+		}
+
+		// 阅读器按 XML 解析章节，输出必须是格式良好的 XML
+		decoder := xml.NewDecoder(strings.NewReader(out))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("%s: output is not well-formed XML: %v\n%s", mode, err, out)
+				break
+			}
+		}
+	}
+
+	// 没有译文时整个文件逐字节不变
+	epub, err := OpenEPUB(path)
+	if err != nil {
+		t.Fatalf("OpenEPUB failed: %v", err)
+	}
+	epub.InsertTranslation(nil)
+	output := filepath.Join(t.TempDir(), "out.epub")
+	if err := epub.Save(output); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	saved, err := OpenEPUB(output)
+	if err != nil {
+		t.Fatalf("OpenEPUB failed: %v", err)
+	}
+	if got := string(saved.Files["OEBPS/text/a.xhtml"]); got != chapter {
+		t.Errorf("Expected identical chapter, got %s", got)
+	}
+}
@@ -2,12 +2,14 @@ package translator
 
 import (
 	"archive/zip"
-	"bytes"
-	"encoding/xml"
 	"fmt"
+	"html"
 	"io"
+	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -16,6 +18,10 @@ type EPUBFile struct {
 	Path     string
 	Files    map[string][]byte
 	Metadata EPUBMetadata
+	// OPFPath OPF 文件在压缩包中的路径
+	OPFPath string
+	// Spine 按阅读顺序排列的内容文件（章节）
+	Spine []SpineItem
 }
 
 type EPUBMetadata struct {
@@ -61,23 +67,22 @@ func OpenEPUB(path string) (*EPUBFile, error) {
 	return epub, nil
 }
 
-// parseMetadata 解析 EPUB 元数据
+// parseMetadata 解析 EPUB 元数据和 spine
 func (e *EPUBFile) parseMetadata() error {
-	// 查找 content.opf 文件
-	var opfPath string
-	for name := range e.Files {
-		if strings.HasSuffix(name, ".opf") {
-			opfPath = name
-			break
-		}
+	// 按 container.xml 查找 OPF 文件
+	e.OPFPath = findOPF(e.Files)
+	if e.OPFPath == "" {
+		return fmt.Errorf("未找到 OPF 文件")
 	}
 
-	if opfPath == "" {
-		return fmt.Errorf("未找到 OPF 文件")
+	spine, err := parseSpine(e.OPFPath, e.Files[e.OPFPath])
+	if err != nil {
+		log.Printf("%v，按文件名顺序处理 HTML 文件", err)
 	}
+	e.Spine = spine
 
 	// 简单解析（实际应该用完整的 XML 解析）
-	content := string(e.Files[opfPath])
+	content := string(e.Files[e.OPFPath])
 	e.Metadata.Title = extractXMLTag(content, "dc:title")
 	e.Metadata.Author = extractXMLTag(content, "dc:creator")
 	e.Metadata.Language = extractXMLTag(content, "dc:language")
@@ -85,15 +90,25 @@ func (e *EPUBFile) parseMetadata() error {
 	return nil
 }
 
-// GetHTMLFiles 获取所有 HTML/XHTML 内容文件
+// GetHTMLFiles 按 spine 的阅读顺序返回 HTML/XHTML 内容文件，包括 linear="no" 的文件；不在 spine 中的文件不处理
+// 没有可用的 spine（找不到或无法解析 OPF）时按文件名顺序返回所有 HTML 文件
 func (e *EPUBFile) GetHTMLFiles() []string {
 	var htmlFiles []string
+	for _, item := range e.Spine {
+		if _, ok := e.Files[item.Path]; ok && isHTMLFile(item.Path, item.MediaType) {
+			htmlFiles = append(htmlFiles, item.Path)
+		}
+	}
+	if len(htmlFiles) > 0 {
+		return htmlFiles
+	}
+
 	for name := range e.Files {
-		ext := strings.ToLower(filepath.Ext(name))
-		if ext == ".html" || ext == ".xhtml" || ext == ".htm" {
+		if isHTMLFile(name, "") {
 			htmlFiles = append(htmlFiles, name)
 		}
 	}
+	sort.Strings(htmlFiles)
 	return htmlFiles
 }
 
@@ -131,15 +146,15 @@ func (e *EPUBFile) SaveEPUB(outputPath string) error {
 
 // extractXMLTag 简单提取 XML 标签内容
 func extractXMLTag(content, tag string) string {
-	start := strings.Index(content, "<"+tag)
-	if start == -1 {
+	tagStart := strings.Index(content, "<"+tag)
+	if tagStart == -1 {
 		return ""
 	}
-	start = strings.Index(content[start:], ">")
-	if start == -1 {
+	gt := strings.Index(content[tagStart:], ">")
+	if gt == -1 {
 		return ""
 	}
-	start += strings.Index(content[:start], "<"+tag) + 1
+	start := tagStart + gt + 1
 
 	end := strings.Index(content[start:], "</"+tag+">")
 	if end == -1 {
@@ -158,204 +173,39 @@ type HTMLContent struct {
 func ParseHTML(content []byte) (*HTMLContent, error) {
 	// 简单提取 body 内容
 	str := string(content)
-	bodyStart := strings.Index(str, "<body")
-	if bodyStart == -1 {
-		return &HTMLContent{Body: str}, nil
-	}
-
-	bodyStart = strings.Index(str[bodyStart:], ">") + bodyStart + 1
-	bodyEnd := strings.Index(str, "</body>")
-	if bodyEnd == -1 {
-		bodyEnd = len(str)
-	}
-
+	start, end := bodyRange(str)
 	return &HTMLContent{
-		Body: str[bodyStart:bodyEnd],
+		Body: str[start:end],
 	}, nil
 }
 
-// ExtractTextBlocks 提取文本块
-func ExtractTextBlocks(html string) []string {
-	// 预处理HTML，清理字符实体和格式化
-	html = strings.ReplaceAll(html, "&#13;", "")
-	html = strings.ReplaceAll(html, "\r", "")
-	html = strings.ReplaceAll(html, "\n", " ")
-
-	// 将<br/>标签替换为换行符，便于后续分割
-	html = strings.ReplaceAll(html, "<br/>", "\n")
-	html = strings.ReplaceAll(html, "<br>", "\n")
-	html = strings.ReplaceAll(html, "<BR/>", "\n")
-	html = strings.ReplaceAll(html, "<BR>", "\n")
-
-	// 尝试XML解析，如果失败则使用备用方法
-	xmlBlocks := extractTextBlocksXML(html)
-	if len(xmlBlocks) > 0 {
-		return xmlBlocks
-	}
-
-	// XML解析失败时的备用方法
-	return extractTextBlocksRegex(html)
-}
-
-// extractTextBlocksXML 使用XML解析提取文本块
-func extractTextBlocksXML(html string) []string {
-	var blocks []string
-	decoder := xml.NewDecoder(strings.NewReader(html))
-	var currentText strings.Builder
-	var inSpan bool
-	var inFont bool
-	var inBlockElement bool
-	var inBold bool
-
-	for {
-		token, err := decoder.Token()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			// XML解析失败，返回空切片让备用方法处理
-			return []string{}
-		}
-
-		switch t := token.(type) {
-		case xml.StartElement:
-			// 检测 span 标签的开始
-			if t.Name.Local == "span" {
-				inSpan = true
-			}
-			// 检测 font 标签的开始（通常用于例句）
-			if t.Name.Local == "font" {
-				inFont = true
-				// 如果当前有文本，先保存为一个块
-				if currentText.Len() > 0 {
-					text := strings.TrimSpace(currentText.String())
-					if text != "" && shouldExtractText(text) {
-						blocks = append(blocks, text)
-					}
-					currentText.Reset()
-				}
-			}
-			// 检测 b 标签（粗体，通常是单词）
-			if t.Name.Local == "b" {
-				inBold = true
-				// 如果当前有文本，先保存为一个块
-				if currentText.Len() > 0 {
-					text := strings.TrimSpace(currentText.String())
-					if text != "" && shouldExtractText(text) {
-						blocks = append(blocks, text)
-					}
-					currentText.Reset()
-				}
-			}
-			// 检测块级元素
-			if isBlockElement(t.Name.Local) {
-				inBlockElement = true
-			}
-
-		case xml.CharData:
-			text := string(t)
-			// 清理多余的空白字符
-			text = cleanText(text)
-			if text != "" {
-				if currentText.Len() > 0 {
-					currentText.WriteString(" ")
-				}
-				currentText.WriteString(text)
-			}
-
-		case xml.EndElement:
-			// span 标签结束时，如果有内容则作为一个独立的文本块
-			if t.Name.Local == "span" && inSpan {
-				if currentText.Len() > 0 {
-					text := strings.TrimSpace(currentText.String())
-					if text != "" && shouldExtractText(text) {
-						blocks = append(blocks, text)
-					}
-					currentText.Reset()
-				}
-				inSpan = false
-			} else if t.Name.Local == "font" && inFont {
-				// font 标签结束时，保存为独立的文本块（通常是例句）
-				if currentText.Len() > 0 {
-					text := strings.TrimSpace(currentText.String())
-					if text != "" && shouldExtractText(text) {
-						blocks = append(blocks, text)
-					}
-					currentText.Reset()
-				}
-				inFont = false
-			} else if t.Name.Local == "b" && inBold {
-				// b 标签结束时，保存为独立的文本块（通常是单词）
-				if currentText.Len() > 0 {
-					text := strings.TrimSpace(currentText.String())
-					if text != "" && shouldExtractText(text) {
-						blocks = append(blocks, text)
-					}
-					currentText.Reset()
-				}
-				inBold = false
-			} else if isBlockElement(t.Name.Local) && inBlockElement {
-				// 块级元素结束时，如果还有未处理的文本，也添加进去
-				if currentText.Len() > 0 {
-					text := strings.TrimSpace(currentText.String())
-					if text != "" && shouldExtractText(text) {
-						blocks = append(blocks, text)
-					}
-					currentText.Reset()
-				}
-				inBlockElement = false
-			}
-		}
+// bodyRange 返回 body 内容在 HTML 中的范围，没有 <body> 时为整个文件
+func bodyRange(content string) (start, end int) {
+	bodyStart := strings.Index(content, "<body")
+	if bodyStart == -1 {
+		return 0, len(content)
 	}
 
-	// 处理剩余的文本
-	if currentText.Len() > 0 {
-		text := strings.TrimSpace(currentText.String())
-		if text != "" && shouldExtractText(text) {
-			blocks = append(blocks, text)
-		}
+	start = strings.Index(content[bodyStart:], ">") + bodyStart + 1
+	end = strings.Index(content, "</body>")
+	if end == -1 {
+		end = len(content)
 	}
-
-	return blocks
+	return start, end
 }
 
-// extractTextBlocksRegex 使用正则表达式提取文本块（备用方法）
-func extractTextBlocksRegex(html string) []string {
-	var blocks []string
-
-	// 移除所有HTML标签，但保留文本内容
-	// 先处理特殊的块级元素，在它们前后添加分隔符
-	html = strings.ReplaceAll(html, "</p>", "</p>\n\n")
-	html = strings.ReplaceAll(html, "</div>", "</div>\n\n")
-	html = strings.ReplaceAll(html, "</h1>", "</h1>\n\n")
-	html = strings.ReplaceAll(html, "</h2>", "</h2>\n\n")
-	html = strings.ReplaceAll(html, "</h3>", "</h3>\n\n")
-	html = strings.ReplaceAll(html, "</li>", "</li>\n")
-	html = strings.ReplaceAll(html, "<br>", "\n")
-	html = strings.ReplaceAll(html, "<br/>", "\n")
-
-	// 移除所有HTML标签
-	var result strings.Builder
-	inTag := false
-	for _, char := range html {
-		if char == '<' {
-			inTag = true
-		} else if char == '>' {
-			inTag = false
-		} else if !inTag {
-			result.WriteRune(char)
-		}
+// ExtractTextBlocks 提取文本块
+// 用 HTML5 分词器解析，规范的 XHTML 和不规范的 HTML 得到同样的划分
+func ExtractTextBlocks(body string) []string {
+	tree, err := parseBody(body)
+	if err != nil {
+		return nil
 	}
 
-	// 按行分割并清理
-	lines := strings.Split(result.String(), "\n")
-	for _, line := range lines {
-		line = cleanText(line)
-		if line != "" && shouldExtractText(line) {
-			blocks = append(blocks, line)
-		}
+	var blocks []string
+	for _, seg := range collectSegments(tree.root) {
+		blocks = append(blocks, seg.text)
 	}
-
 	return blocks
 }
 
@@ -391,6 +241,7 @@ func cleanText(text string) string {
 	// 移除HTML实体
 	text = strings.ReplaceAll(text, "&#13;", "")
 	text = strings.ReplaceAll(text, "&nbsp;", " ")
+	text = strings.ReplaceAll(text, "\u00a0", " ")
 	text = strings.ReplaceAll(text, "&", "&")
 	text = strings.ReplaceAll(text, "<", "<")
 	text = strings.ReplaceAll(text, ">", ">")
@@ -410,247 +261,115 @@ func cleanText(text string) string {
 	return strings.TrimSpace(text)
 }
 
-// isBlockElement 判断是否为块级元素
+// isBlockElement 判断元素是否划分文本块：不在 inlineElements 中的元素都按块级元素处理
 func isBlockElement(tag string) bool {
-	blockTags := map[string]bool{
-		"p": true, "div": true, "h1": true, "h2": true, "h3": true,
-		"h4": true, "h5": true, "h6": true, "li": true, "blockquote": true,
-	}
-	return blockTags[tag]
+	return !inlineElements[tag]
 }
 
-// InsertTranslation 插入翻译（双语显示）
-func InsertTranslation(html string, translations map[string]string) string {
-	// 尝试使用XML解析方式插入翻译
-	result := insertTranslationXML(html, translations)
-	if result != html {
-		return result
+// rewriteSegments 按文本块 ID 查找 file 中 body 内容的译文，对有译文的文本块执行 apply
+// 只修改对应的节点，不会改动属性、<title> 或其他文本中相同的字符串；没有译文或解析失败时返回原内容
+func rewriteSegments(file, body string, translations map[SegmentID]string, apply func(textSegment, string)) string {
+	tree, err := parseBody(body)
+	if err != nil {
+		return body
 	}
 
-	// XML解析失败时使用备用方法
-	return insertTranslationRegex(html, translations)
-}
-
-// insertTranslationXML 使用XML解析插入翻译
-func insertTranslationXML(html string, translations map[string]string) string {
-	var buf bytes.Buffer
-	decoder := xml.NewDecoder(strings.NewReader(html))
-	var currentText strings.Builder
-	var depth int
-	var inSpan bool
-	var inFont bool
-
-	for {
-		token, err := decoder.Token()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			// 解析失败，返回原文让备用方法处理
-			return html
-		}
-
-		switch t := token.(type) {
-		case xml.StartElement:
-			buf.WriteString("<")
-			buf.WriteString(t.Name.Local)
-			for _, attr := range t.Attr {
-				buf.WriteString(fmt.Sprintf(` %s="%s"`, attr.Name.Local, attr.Value))
-			}
-			buf.WriteString(">")
-			depth++
-
-			// 检测 span 标签
-			if t.Name.Local == "span" {
-				inSpan = true
-			}
-			// 检测 font 标签
-			if t.Name.Local == "font" {
-				inFont = true
-				// 如果当前有文本，先插入翻译
-				if currentText.Len() > 0 {
-					original := strings.TrimSpace(currentText.String())
-					if trans, ok := translations[original]; ok && trans != "" {
-						buf.WriteString(fmt.Sprintf(`<div class="translation" style="color: #666; font-style: italic; margin-top: 0.5em;">%s</div>`, trans))
-					}
-					currentText.Reset()
-				}
-			}
-			// br 标签处理
-			if t.Name.Local == "br" {
-				if currentText.Len() > 0 {
-					original := strings.TrimSpace(currentText.String())
-					if trans, ok := translations[original]; ok && trans != "" {
-						buf.WriteString(fmt.Sprintf(`<div class="translation" style="color: #666; font-style: italic; margin-top: 0.5em;">%s</div>`, trans))
-					}
-					currentText.Reset()
-				}
-			}
-
-		case xml.EndElement:
-			// 在 span 结束标签后插入翻译
-			if t.Name.Local == "span" && inSpan && currentText.Len() > 0 {
-				original := strings.TrimSpace(currentText.String())
-				buf.WriteString("</")
-				buf.WriteString(t.Name.Local)
-				buf.WriteString(">")
-
-				// 在 span 后面添加翻译（使用 span 标签保持格式一致）
-				if trans, ok := translations[original]; ok && trans != "" {
-					buf.WriteString(fmt.Sprintf(`<span class="translation" style="color: #666; font-style: italic;"> [%s]</span>`, trans))
-				}
-				currentText.Reset()
-				inSpan = false
-			} else if t.Name.Local == "font" && inFont && currentText.Len() > 0 {
-				// 在 font 结束标签后插入翻译
-				original := strings.TrimSpace(currentText.String())
-				buf.WriteString("</")
-				buf.WriteString(t.Name.Local)
-				buf.WriteString(">")
-
-				// 在 font 后面添加翻译
-				if trans, ok := translations[original]; ok && trans != "" {
-					buf.WriteString(fmt.Sprintf(`<div class="translation" style="color: #666; font-style: italic; margin-top: 0.5em;">%s</div>`, trans))
-				}
-				currentText.Reset()
-				inFont = false
-			} else {
-				// 在块级元素结束标签前插入翻译
-				if isBlockElement(t.Name.Local) && currentText.Len() > 0 {
-					original := strings.TrimSpace(currentText.String())
-					if trans, ok := translations[original]; ok && trans != "" {
-						buf.WriteString(fmt.Sprintf(`<div class="translation" style="color: #666; font-style: italic; margin-top: 0.5em;">%s</div>`, trans))
-					}
-					currentText.Reset()
-				}
-				buf.WriteString("</")
-				buf.WriteString(t.Name.Local)
-				buf.WriteString(">")
-			}
-			depth--
-
-		case xml.CharData:
-			text := string(t)
-			buf.WriteString(text)
-			currentText.WriteString(strings.TrimSpace(text))
-			currentText.WriteString(" ")
+	changed := false
+	for _, seg := range collectSegments(tree.root) {
+		if trans := translations[SegmentID(file+"#"+seg.id)]; trans != "" {
+			apply(seg, trans)
+			changed = true
 		}
 	}
-
-	return buf.String()
-}
-
-// insertTranslationRegex 使用正则表达式插入翻译（备用方法）
-func insertTranslationRegex(html string, translations map[string]string) string {
-	result := html
-
-	// 为每个翻译对创建替换
-	for original, translation := range translations {
-		if translation == "" || original == "" {
-			continue
-		}
-
-		// 转义特殊字符
-		// Note: strings.ReplaceAll uses literal string matching, not regex,
-		// so escaping is not needed
-
-		// 简单的文本替换，在原文后添加翻译
-		translationHTML := fmt.Sprintf(`<span class="translation" style="color: #666; font-style: italic;"> [%s]</span>`, translation)
-
-		// 查找并替换
-		if strings.Contains(result, original) {
-			result = strings.ReplaceAll(result, original, original+translationHTML)
-		}
+	if !changed {
+		return body
 	}
 
+	result, err := tree.render()
+	if err != nil {
+		return body
+	}
 	return result
 }
 
-// GetTextBlocks 获取文本块（实现 Document 接口）
-func (e *EPUBFile) GetTextBlocks() []string {
-	var allBlocks []string
-
-	htmlFiles := e.GetHTMLFiles()
-	for _, filename := range htmlFiles {
+// GetSegments 按阅读顺序返回所有文本块（实现 Document 接口）
+func (e *EPUBFile) GetSegments() []Segment {
+	var segments []Segment
+	for _, filename := range e.GetHTMLFiles() {
 		content := e.Files[filename]
 		htmlContent, err := ParseHTML(content)
 		if err != nil {
 			continue
 		}
-
-		blocks := ExtractTextBlocks(htmlContent.Body)
-		allBlocks = append(allBlocks, blocks...)
-	}
-
-	return allBlocks
-}
-
-// InsertTranslation 插入翻译（实现 Document 接口）
-func (e *EPUBFile) InsertTranslation(translations map[string]string) error {
-	htmlFiles := e.GetHTMLFiles()
-
-	for _, filename := range htmlFiles {
-		content := e.Files[filename]
-		htmlContent, err := ParseHTML(content)
+		tree, err := parseBody(htmlContent.Body)
 		if err != nil {
 			continue
 		}
 
-		// 插入翻译
-		translatedBody := InsertTranslation(htmlContent.Body, translations)
-
-		// 重新构建完整的 HTML
-		originalStr := string(content)
-		bodyStart := strings.Index(originalStr, "<body")
-		if bodyStart == -1 {
-			continue
+		chapter := chapterTitle(string(content))
+		for _, seg := range collectSegments(tree.root) {
+			segments = append(segments, Segment{
+				ID:      SegmentID(filename + "#" + seg.id),
+				File:    filename,
+				Text:    seg.text,
+				Chapter: chapter,
+				Context: seg.container.Data,
+			})
 		}
-
-		bodyStartEnd := strings.Index(originalStr[bodyStart:], ">") + bodyStart + 1
-		bodyEnd := strings.Index(originalStr, "</body>")
-		if bodyEnd == -1 {
-			bodyEnd = len(originalStr)
-		}
-
-		newContent := originalStr[:bodyStartEnd] + translatedBody + originalStr[bodyEnd:]
-		e.Files[filename] = []byte(newContent)
 	}
+	return segments
+}
 
-	return nil
+// GetTextBlocks 按阅读顺序返回所有文本块的原文
+func (e *EPUBFile) GetTextBlocks() []string {
+	return segmentTexts(e.GetSegments())
 }
 
-// InsertMonolingualTranslation 插入单语翻译（实现 Document 接口）
-func (e *EPUBFile) InsertMonolingualTranslation(translations map[string]string) error {
-	htmlFiles := e.GetHTMLFiles()
+var (
+	// headingRegex 章节标题使用的第一个 h1~h3
+	headingRegex = regexp.MustCompile(`(?is)<h[1-3]\b[^>]*>(.*?)</h[1-3]>`)
+	// tagRegex 标题中的内联标签
+	tagRegex = regexp.MustCompile(`<[^>]*>`)
+)
 
-	for _, filename := range htmlFiles {
-		content := e.Files[filename]
-		htmlContent, err := ParseHTML(content)
-		if err != nil {
-			continue
-		}
+// chapterTitle 返回 HTML 文件的章节标题：第一个 h1~h3，没有时使用 <title>
+func chapterTitle(content string) string {
+	title := ""
+	if m := headingRegex.FindStringSubmatch(content); m != nil {
+		title = m[1]
+	}
+	if strings.TrimSpace(tagRegex.ReplaceAllString(title, "")) == "" {
+		title = extractXMLTag(content, "title")
+	}
+	return cleanText(html.UnescapeString(tagRegex.ReplaceAllString(title, " ")))
+}
 
-		// 插入单语翻译（替换原文）
-		translatedBody := InsertMonolingualTranslation(htmlContent.Body, translations)
+// InsertTranslation 插入翻译（实现 Document 接口），translations 的键为 Segment.ID
+func (e *EPUBFile) InsertTranslation(translations map[SegmentID]string) error {
+	e.rewriteBodies(func(filename, body string) string {
+		return rewriteSegments(filename, body, translations, textSegment.insertBilingual)
+	})
+	return nil
+}
 
-		// 重新构建完整的 HTML
-		originalStr := string(content)
-		bodyStart := strings.Index(originalStr, "<body")
-		if bodyStart == -1 {
-			continue
-		}
+// InsertMonolingualTranslation 插入单语翻译（实现 Document 接口），translations 的键为 Segment.ID
+func (e *EPUBFile) InsertMonolingualTranslation(translations map[SegmentID]string) error {
+	e.rewriteBodies(func(filename, body string) string {
+		return rewriteSegments(filename, body, translations, textSegment.replaceText)
+	})
+	return nil
+}
 
-		bodyStartEnd := strings.Index(originalStr[bodyStart:], ">") + bodyStart + 1
-		bodyEnd := strings.Index(originalStr, "</body>")
-		if bodyEnd == -1 {
-			bodyEnd = len(originalStr)
+// rewriteBodies 改写每个 HTML 文件的 body 内容，body 之外的部分（XML 声明、head 等）保持不变
+func (e *EPUBFile) rewriteBodies(rewrite func(filename, body string) string) {
+	for _, filename := range e.GetHTMLFiles() {
+		content := string(e.Files[filename])
+		start, end := bodyRange(content)
+		body := rewrite(filename, content[start:end])
+		if body != content[start:end] {
+			e.Files[filename] = []byte(content[:start] + body + content[end:])
 		}
-
-		newContent := originalStr[:bodyStartEnd] + translatedBody + originalStr[bodyEnd:]
-		e.Files[filename] = []byte(newContent)
 	}
-
-	return nil
 }
 
 // Save 保存文档（实现 Document 接口）
@@ -673,168 +392,3 @@ func ValidateEPUB(filePath string) error {
 
 	return nil
 }
-
-// InsertMonolingualTranslation 插入单语翻译（替换原文）
-func InsertMonolingualTranslation(html string, translations map[string]string) string {
-	// 尝试使用XML解析方式插入翻译
-	result := insertMonolingualTranslationXML(html, translations)
-	if result != html {
-		return result
-	}
-
-	// XML解析失败时使用备用方法
-	return insertMonolingualTranslationRegex(html, translations)
-}
-
-// insertMonolingualTranslationXML 使用XML解析插入单语翻译
-func insertMonolingualTranslationXML(html string, translations map[string]string) string {
-	var buf bytes.Buffer
-	decoder := xml.NewDecoder(strings.NewReader(html))
-	var currentText strings.Builder
-	var depth int
-	var inSpan bool
-	var inFont bool
-
-	for {
-		token, err := decoder.Token()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			// 解析失败，返回原文让备用方法处理
-			return html
-		}
-
-		switch t := token.(type) {
-		case xml.StartElement:
-			buf.WriteString("<")
-			buf.WriteString(t.Name.Local)
-			for _, attr := range t.Attr {
-				buf.WriteString(fmt.Sprintf(` %s="%s"`, attr.Name.Local, attr.Value))
-			}
-			buf.WriteString(">")
-			depth++
-
-			// 检测 span 标签
-			if t.Name.Local == "span" {
-				inSpan = true
-			}
-			// 检测 font 标签
-			if t.Name.Local == "font" {
-				inFont = true
-			}
-			// br 标签处理
-			if t.Name.Local == "br" {
-				if currentText.Len() > 0 {
-					original := strings.TrimSpace(currentText.String())
-					if trans, ok := translations[original]; ok && trans != "" {
-						// 需要回退并替换之前写入的原文
-						// 这里简化处理，直接清空并写入翻译
-					}
-					currentText.Reset()
-				}
-			}
-
-		case xml.EndElement:
-			// 在 span 结束标签前替换内容
-			if t.Name.Local == "span" && inSpan && currentText.Len() > 0 {
-				original := strings.TrimSpace(currentText.String())
-				// 如果有翻译，使用翻译；否则保留原文
-				if trans, ok := translations[original]; ok && trans != "" {
-					// 清除之前写入的原文内容，写入翻译
-					bufStr := buf.String()
-					lastSpanStart := strings.LastIndex(bufStr, "<span")
-					if lastSpanStart != -1 {
-						// 找到span开始标签的结束位置
-						spanTagEnd := strings.Index(bufStr[lastSpanStart:], ">")
-						if spanTagEnd != -1 {
-							spanTagEnd += lastSpanStart + 1
-							// 重构buffer：保留到span标签结束，然后加入翻译内容
-							buf.Reset()
-							buf.WriteString(bufStr[:spanTagEnd])
-							buf.WriteString(trans)
-						}
-					}
-				}
-				buf.WriteString("</")
-				buf.WriteString(t.Name.Local)
-				buf.WriteString(">")
-				currentText.Reset()
-				inSpan = false
-			} else if t.Name.Local == "font" && inFont && currentText.Len() > 0 {
-				// 在 font 结束标签前替换内容
-				original := strings.TrimSpace(currentText.String())
-				if trans, ok := translations[original]; ok && trans != "" {
-					bufStr := buf.String()
-					lastFontStart := strings.LastIndex(bufStr, "<font")
-					if lastFontStart != -1 {
-						fontTagEnd := strings.Index(bufStr[lastFontStart:], ">")
-						if fontTagEnd != -1 {
-							fontTagEnd += lastFontStart + 1
-							buf.Reset()
-							buf.WriteString(bufStr[:fontTagEnd])
-							buf.WriteString(trans)
-						}
-					}
-				}
-				buf.WriteString("</")
-				buf.WriteString(t.Name.Local)
-				buf.WriteString(">")
-				currentText.Reset()
-				inFont = false
-			} else {
-				// 在块级元素结束标签前替换内容
-				if isBlockElement(t.Name.Local) && currentText.Len() > 0 {
-					original := strings.TrimSpace(currentText.String())
-					if trans, ok := translations[original]; ok && trans != "" {
-						// 对于块级元素，需要更复杂的替换逻辑
-						// 这里简化处理，直接在结束标签前添加翻译
-						buf.WriteString(fmt.Sprintf(`<div class="translation">%s</div>`, trans))
-					}
-					currentText.Reset()
-				}
-				buf.WriteString("</")
-				buf.WriteString(t.Name.Local)
-				buf.WriteString(">")
-			}
-			depth--
-
-		case xml.CharData:
-			text := string(t)
-			if inSpan || inFont {
-				// 对于span和font内的文本，先收集，在结束标签时处理
-				currentText.WriteString(strings.TrimSpace(text))
-				currentText.WriteString(" ")
-			} else {
-				// 对于非span/font文本，检查是否需要替换
-				trimmedText := strings.TrimSpace(text)
-				if trans, ok := translations[trimmedText]; ok && trans != "" && trimmedText != "" {
-					buf.WriteString(trans)
-				} else {
-					buf.WriteString(text)
-				}
-			}
-		}
-	}
-
-	return buf.String()
-}
-
-// insertMonolingualTranslationRegex 使用正则表达式插入单语翻译（备用方法）
-func insertMonolingualTranslationRegex(html string, translations map[string]string) string {
-	result := html
-
-	// 为每个翻译对创建替换
-	for original, translation := range translations {
-		if translation == "" || original == "" {
-			continue
-		}
-
-		// 直接替换原文为翻译
-		if strings.Contains(result, original) {
-			result = strings.ReplaceAll(result, original, translation)
-		}
-	}
-
-	return result
-}
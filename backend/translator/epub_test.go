@@ -0,0 +1,183 @@
+package translator
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testOPF 两章的 OPF，spine 顺序与文件名顺序相反
+const testOPF = `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Test Book</dc:title>
+    <dc:creator>Tester</dc:creator>
+    <dc:language>en</dc:language>
+  </metadata>
+  <manifest>
+    <item id="b" href="text/b.xhtml" media-type="application/xhtml+xml"/>
+    <item id="a" href="text/a.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="b"/>
+    <itemref idref="a"/>
+  </spine>
+</package>`
+
+// testChapter 生成包含若干段落的章节
+func testChapter(paragraphs ...string) string {
+	body := ""
+	for _, p := range paragraphs {
+		body += "<p>" + p + "</p>\n"
+	}
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title>t</title></head><body>
+` + body + `</body></html>`
+}
+
+// writeTestEPUB 把文件写成 EPUB 压缩包，返回路径
+func writeTestEPUB(t *testing.T, files map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.epub")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create epub failed: %v", err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("create zip entry failed: %v", err)
+		}
+		fw.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close zip failed: %v", err)
+	}
+	return path
+}
+
+func TestGetHTMLFiles_SpineOrder(t *testing.T) {
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":   testOPF,
+		"OEBPS/text/a.xhtml":  testChapter("Chapter A text."),
+		"OEBPS/text/b.xhtml":  testChapter("Chapter B text."),
+		"OEBPS/text/z.xhtml":  testChapter("Not in spine."),
+		"OEBPS/text/nav.html": testChapter("Navigation."),
+	})
+	epub, err := OpenEPUB(path)
+	if err != nil {
+		t.Fatalf("OpenEPUB failed: %v", err)
+	}
+
+	// 不在 spine 中的文件不处理
+	want := []string{"OEBPS/text/b.xhtml", "OEBPS/text/a.xhtml"}
+	if got := epub.GetHTMLFiles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if got := epub.GetTextBlocks(); !reflect.DeepEqual(got, []string{"Chapter B text.", "Chapter A text."}) {
+		t.Errorf("Expected text blocks in spine order, got %v", got)
+	}
+}
+
+func TestParseMetadata(t *testing.T) {
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  testOPF,
+		"OEBPS/text/a.xhtml": testChapter("Chapter A text."),
+	})
+	epub, err := OpenEPUB(path)
+	if err != nil {
+		t.Fatalf("OpenEPUB failed: %v", err)
+	}
+
+	want := EPUBMetadata{Title: "Test Book", Author: "Tester", Language: "en"}
+	if epub.Metadata != want {
+		t.Errorf("Expected %+v, got %+v", want, epub.Metadata)
+	}
+}
+
+func TestExtractTextBlocks_SloppyMarkup(t *testing.T) {
+	want := []string{"Chapter One", "Hello, <x1>world</x1>!", "Fish & chips are served.", "Line one<x1/>line two"}
+	valid := `<h1>Chapter One</h1>
+<p>Hello, <em>world</em>!</p>
+<p>Fish &amp; chips are served.</p>
+<p>Line one<br/>line two</p>
+<script>var skipped = "not text";</script>`
+	// 大写标签、未闭合的 <p>、未转义的 &、不带斜杠的 <br>
+	sloppy := `<H1>Chapter One</H1>
+<P>Hello, <em>world</em>!
+<p>Fish & chips are served.
+<p>Line one<br>line two`
+
+	for name, body := range map[string]string{"valid": valid, "sloppy": sloppy} {
+		if got := ExtractTextBlocks(body); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+
+	// XHTML 自闭合的锚点不会吞掉后面的文本
+	got := ExtractTextBlocks(`<p>Before the anchor <a id="note"/> and after it.</p><p>Next paragraph.</p>`)
+	if want := []string{"Before the anchor <x1/> and after it.", "Next paragraph."}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	// 自闭合的原始文本元素之后的标签仍按标签解析
+	body := `<p>First paragraph here.</p><script src="x.js"/><p>Second paragraph here.</p><style/><title/>` +
+		`<p>Third paragraph here.</p><textarea/><iframe src="a.html"/><noscript/><p>Fourth paragraph here.</p>`
+	got = ExtractTextBlocks(body)
+	if want := []string{"First paragraph here.", "Second paragraph here.", "Third paragraph here.", "Fourth paragraph here."}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got := insertBody(body, map[string]string{}, textSegment.replaceText); got != body {
+		t.Errorf("Expected self-closing raw text elements to round trip, got %q", got)
+	}
+}
+
+func TestInsertTranslation_OnlyTextNodes(t *testing.T) {
+	body := `<p><img src="cat.png" alt="The cat sat."/>The cat sat.</p>
+<p>Then the cat sat. down again</p>`
+	translations := map[string]string{"<x1/>The cat sat.": "<x1/>猫坐下了。"}
+
+	got := insertBody(body, translations, textSegment.insertBilingual)
+	if strings.Count(got, "猫坐下了。") != 1 || !strings.Contains(got, `alt="The cat sat."`) {
+		t.Errorf("Expected one translation and the attribute untouched, got %s", got)
+	}
+	if !strings.Contains(got, `The cat sat.<div class="translation"`) || !strings.Contains(got, "Then the cat sat. down again</p>") {
+		t.Errorf("Expected the translation after its own paragraph only, got %s", got)
+	}
+
+	got = insertBody(body, translations, textSegment.replaceText)
+	if strings.Count(got, "猫坐下了。") != 1 || !strings.Contains(got, `alt="The cat sat."`) || !strings.Contains(got, "Then the cat sat. down again") {
+		t.Errorf("Expected only the matching paragraph replaced, got %s", got)
+	}
+}
+
+func TestInsertTranslation_SameForSloppyMarkup(t *testing.T) {
+	translations := map[string]string{"Hello, <x1>world</x1>!": "你好，<x1>世界</x1>！", "Fish & chips are served.": "炸鱼薯条 <上桌>"}
+	valid := `<p>Hello, <em>world</em>!</p><p>Fish &amp; chips are served.</p>`
+	sloppy := `<P>Hello, <em>world</em>!<p>Fish & chips are served.`
+
+	for name, body := range map[string]string{"valid": valid, "sloppy": sloppy} {
+		// 原文的标签按原样保留，译文紧跟在对应的原文之后
+		bilingual := insertBody(body, translations, textSegment.insertBilingual)
+		want := `Hello, <em>world</em>!<div class="translation" style="color: #666; font-style: italic; margin-top: 0.5em;">你好，<em>世界</em>！</div>`
+		if !strings.Contains(bilingual, want) || !strings.Contains(bilingual, "chips are served.<div") || !strings.Contains(bilingual, "炸鱼薯条 &lt;上桌&gt;</div>") {
+			t.Errorf("%s: unexpected bilingual output %s", name, bilingual)
+		}
+
+		monolingual := insertBody(body, translations, textSegment.replaceText)
+		if !strings.Contains(strings.ToLower(monolingual), "<p>你好，<em>世界</em>！") || strings.Contains(monolingual, "Hello") || strings.Contains(monolingual, "chips") {
+			t.Errorf("%s: unexpected monolingual output %s", name, monolingual)
+		}
+	}
+
+	// 没有译文时保持原样
+	if got := insertBody(valid, nil, textSegment.insertBilingual); got != valid {
+		t.Errorf("Expected unchanged body, got %s", got)
+	}
+}
@@ -0,0 +1,251 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// TermCandidate 候选术语
+type TermCandidate struct {
+	Term    string `json:"term"`
+	Count   int    `json:"count"`
+	Example string `json:"example"` // 出现该术语的一段原文，帮助模型判断含义
+}
+
+const (
+	// minTermCount 候选术语的最少出现次数
+	minTermCount = 2
+	// maxTermCandidates 候选术语数量上限，按出现次数保留
+	maxTermCandidates = 300
+	// glossaryChunkSize 每次请求提交的候选术语数
+	glossaryChunkSize = 40
+	// maxExampleLength 示例原文的最大长度（字符数）
+	maxExampleLength = 200
+)
+
+// glossaryExtractionInstruction 术语提取请求的系统提示词
+const glossaryExtractionInstruction = `You are building a translation glossary for a book.
+You will receive a JSON array of candidate terms found in the book, each with an example sentence: [{"term": "...", "example": "..."}].
+Keep only proper nouns (people, places, organizations) and recurring invented or domain-specific terms; drop ordinary words.
+For each kept term, propose the translation that should be used consistently throughout the book. Set "doNotTranslate" to true for terms that should stay in the original form (for example brand names or code).
+Return ONLY a JSON array: [{"source": "<term>", "target": "<translation>", "doNotTranslate": false}]. Do not wrap the JSON in code fences and do not add explanations.`
+
+// commonCapitalized 句中也常大写但不是术语的词
+var commonCapitalized = map[string]bool{
+	"I": true, "I'm": true, "I've": true, "I'll": true, "I'd": true,
+	"Mr": true, "Mrs": true, "Ms": true, "Dr": true, "Sir": true,
+	"Monday": true, "Tuesday": true, "Wednesday": true, "Thursday": true, "Friday": true, "Saturday": true, "Sunday": true,
+	"January": true, "February": true, "March": true, "April": true, "May": true, "June": true,
+	"July": true, "August": true, "September": true, "October": true, "November": true, "December": true,
+	"Chapter": true, "Part": true, "OK": true,
+}
+
+// ExtractTermCandidates 用词频和大小写启发式从文本块中收集候选术语
+// 连续的大写开头单词（不在句首，或在句首但在别处也以大写出现）视为一个候选，出现次数不少于 minTermCount 时保留
+func ExtractTermCandidates(blocks []string) []TermCandidate {
+	counts := make(map[string]int)
+	examples := make(map[string]string)
+	midSentence := make(map[string]bool) // 曾在句中以大写出现的词
+	startOnly := make(map[string]int)    // 只在句首出现的次数
+
+	for _, block := range blocks {
+		// 内联元素的占位符不属于原文
+		block = stripPlaceholders(block)
+		for _, sentence := range SplitSentences(block) {
+			words := strings.Fields(sentence)
+			var phrase []string
+			phraseAtStart := false
+
+			record := func(parts []string, atStart bool) {
+				term := strings.Join(parts, " ")
+				if atStart {
+					startOnly[term]++
+				} else {
+					midSentence[term] = true
+					counts[term]++
+				}
+				if _, ok := examples[term]; !ok {
+					examples[term] = truncateRunes(sentence, maxExampleLength)
+				}
+			}
+			flush := func() {
+				if len(phrase) == 0 {
+					return
+				}
+				record(phrase, phraseAtStart)
+				// 句首的大写词可能只是普通词（如 Then Frodo），去掉它后剩下的部分按句中出现计数
+				if phraseAtStart && len(phrase) > 1 {
+					record(phrase[1:], false)
+				}
+				phrase = nil
+			}
+
+			for i, raw := range words {
+				word := strings.TrimFunc(raw, func(r rune) bool {
+					return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+				})
+				word = strings.TrimSuffix(strings.TrimSuffix(word, "'s"), "'")
+				if isTermWord(word) {
+					if len(phrase) == 0 {
+						phraseAtStart = i == 0
+					}
+					phrase = append(phrase, word)
+				} else {
+					flush()
+				}
+				// 词后带标点（逗号、冒号等）时短语结束
+				if len(phrase) > 0 && raw != "" && unicode.IsPunct([]rune(raw)[len([]rune(raw))-1]) {
+					flush()
+				}
+			}
+			flush()
+		}
+	}
+
+	// 句首出现的词只有在句中也以大写出现过时才计入
+	for term, n := range startOnly {
+		if midSentence[term] {
+			counts[term] += n
+		}
+	}
+
+	var candidates []TermCandidate
+	for term, count := range counts {
+		if count < minTermCount {
+			continue
+		}
+		candidates = append(candidates, TermCandidate{Term: term, Count: count, Example: examples[term]})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Count != candidates[j].Count {
+			return candidates[i].Count > candidates[j].Count
+		}
+		return candidates[i].Term < candidates[j].Term
+	})
+	if len(candidates) > maxTermCandidates {
+		candidates = candidates[:maxTermCandidates]
+	}
+	return candidates
+}
+
+// isTermWord 判断单词是否可能是术语的一部分（大写开头的拉丁字母单词）
+func isTermWord(word string) bool {
+	if word == "" || commonCapitalized[word] {
+		return false
+	}
+	first := []rune(word)[0]
+	return unicode.IsUpper(first) && unicode.Is(unicode.Latin, first)
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
+
+// ProposeGlossary 从文本块中提取候选术语，并按块请求模型给出译法，返回建议的术语表
+// 单个请求失败时跳过该块；全部失败时返回错误
+func (dt *DocumentTranslator) ProposeGlossary(blocks []string, targetLanguage, userPrompt string) (*Glossary, error) {
+	candidates := ExtractTermCandidates(blocks)
+	log.Printf("术语提取：找到 %d 个候选术语", len(candidates))
+
+	glossary := &Glossary{}
+	if len(candidates) == 0 {
+		return glossary, nil
+	}
+
+	prompt := glossaryExtractionInstruction
+	if userPrompt != "" {
+		prompt += "\n" + userPrompt
+	}
+
+	var lastErr error
+	succeeded := 0
+	for start := 0; start < len(candidates); start += glossaryChunkSize {
+		end := start + glossaryChunkSize
+		if end > len(candidates) {
+			end = len(candidates)
+		}
+		entries, err := dt.proposeChunk(candidates[start:end], targetLanguage, prompt)
+		if err != nil {
+			log.Printf("术语提取请求失败: %v", err)
+			lastErr = err
+			continue
+		}
+		succeeded++
+		glossary.Entries = append(glossary.Entries, entries...)
+	}
+
+	if succeeded == 0 {
+		return nil, fmt.Errorf("术语提取失败: %w", lastErr)
+	}
+	return glossary, nil
+}
+
+// proposeChunk 请求模型为一块候选术语给出译法
+func (dt *DocumentTranslator) proposeChunk(candidates []TermCandidate, targetLanguage, prompt string) ([]GlossaryEntry, error) {
+	type item struct {
+		Term    string `json:"term"`
+		Example string `json:"example"`
+	}
+	items := make([]item, len(candidates))
+	known := make(map[string]bool, len(candidates))
+	for i, c := range candidates {
+		items[i] = item{Term: c.Term, Example: c.Example}
+		known[c.Term] = true
+	}
+	payload, err := json.Marshal(items)
+	if err != nil {
+		return nil, fmt.Errorf("序列化候选术语失败: %w", err)
+	}
+
+	response, _, err := dt.translateStructured(string(payload), targetLanguage, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	start := strings.Index(response, "[")
+	end := strings.LastIndex(response, "]")
+	if start == -1 || end <= start {
+		return nil, fmt.Errorf("response is not a JSON array")
+	}
+	var proposed []GlossaryEntry
+	if err := json.Unmarshal([]byte(response[start:end+1]), &proposed); err != nil {
+		return nil, fmt.Errorf("decode glossary response failed: %w", err)
+	}
+
+	// 只保留提交过的术语，丢弃模型自行添加的
+	var entries []GlossaryEntry
+	for _, e := range proposed {
+		e.Source = strings.TrimSpace(e.Source)
+		e.Target = strings.TrimSpace(e.Target)
+		if !known[e.Source] || (e.Target == "" && !e.DoNotTranslate) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// Merge 合并另一个术语表，已有的原文术语保持不变
+func (g *Glossary) Merge(other *Glossary) {
+	if other == nil {
+		return
+	}
+	existing := make(map[string]bool, len(g.Entries))
+	for _, e := range g.Entries {
+		existing[e.Source] = true
+	}
+	for _, e := range other.Entries {
+		if !existing[e.Source] {
+			existing[e.Source] = true
+			g.Entries = append(g.Entries, e)
+		}
+	}
+}
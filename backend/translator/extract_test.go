@@ -0,0 +1,91 @@
+package translator
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// glossaryClient 把提交的候选术语原样翻译为 T(术语)，并额外返回一个未提交的术语
+type glossaryClient struct {
+	calls int
+}
+
+func (c *glossaryClient) Translate(text, targetLanguage, userPrompt string) (string, error) {
+	c.calls++
+	var items []struct {
+		Term string `json:"term"`
+	}
+	if err := json.Unmarshal([]byte(text), &items); err != nil {
+		return "", err
+	}
+	entries := []GlossaryEntry{{Source: "Invented", Target: "bogus"}}
+	for _, item := range items {
+		entries = append(entries, GlossaryEntry{Source: item.Term, Target: "T(" + item.Term + ")"})
+	}
+	data, _ := json.Marshal(entries)
+	return "```json\n" + string(data) + "\n```", nil
+}
+
+func TestExtractTermCandidates(t *testing.T) {
+	blocks := []string{
+		"Frodo walked to Mount Doom. The road was long.",
+		"Later, Frodo met Gandalf near Mount Doom, and I waited.",
+		"Gandalf smiled. She said nothing.",
+		"She was tired.",
+	}
+	got := make(map[string]int)
+	for _, c := range ExtractTermCandidates(blocks) {
+		got[c.Term] = c.Count
+	}
+
+	if got["Frodo"] != 2 || got["Mount Doom"] != 2 || got["Gandalf"] != 2 {
+		t.Errorf("Expected recurring proper nouns, got %v", got)
+	}
+	for _, term := range []string{"The", "She", "Later", "I"} {
+		if _, ok := got[term]; ok {
+			t.Errorf("Expected %q to be filtered, got %v", term, got)
+		}
+	}
+}
+
+func TestTranslateEPUB_ExtractGlossary(t *testing.T) {
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  testOPF,
+		"OEBPS/text/a.xhtml": testChapter("Then Frodo ran.", "Again Frodo ran."),
+		"OEBPS/text/b.xhtml": testChapter("Nothing here."),
+	})
+
+	client := &glossaryClient{}
+	dt := &DocumentTranslator{
+		Client: client,
+		Cache:  newTestCache(t),
+		Options: TranslateOptions{
+			ExtractGlossary: true,
+			Glossary:        &Glossary{Entries: []GlossaryEntry{{Source: "Frodo", Target: "佛罗多"}}},
+		},
+	}
+	var proposal *Glossary
+	dt.Hooks.OnGlossaryProposal = func(g *Glossary) { proposal = g }
+
+	output := filepath.Join(t.TempDir(), "out.epub")
+	if _, err := dt.TranslateEPUB("extract", path, output, "Chinese", "", "bilingual", nil, nil); err != ErrGlossaryReview {
+		t.Fatalf("Expected ErrGlossaryReview, got %v", err)
+	}
+	if client.calls != 1 {
+		t.Errorf("Expected one extraction request, got %d", client.calls)
+	}
+	if proposal == nil {
+		t.Fatal("Expected proposal hook to be called")
+	}
+	// 已有的术语保持用户的译法，模型自行添加的术语被丢弃
+	for _, e := range proposal.Entries {
+		if e.Source == "Frodo" && e.Target != "佛罗多" {
+			t.Errorf("Expected existing entry to win, got %+v", e)
+		}
+		if strings.Contains(e.Source, "Invented") {
+			t.Errorf("Expected unknown term to be dropped, got %+v", e)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package translator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FallbackProvider 备用链中的一个提供商
+type FallbackProvider struct {
+	Name   string
+	Client TranslatorClientInterface
+}
+
+// FallbackClient 按顺序尝试多个提供商，前一个失败、拒绝或输出不合格时交给下一个
+type FallbackClient struct {
+	Providers []FallbackProvider
+	// Check 校验译文，返回错误时视为不合格并尝试下一个提供商，可为空
+	// 所有提供商都失败、但有提供商的输出只是未通过校验时，返回第一个这样的输出，由调用方决定如何处理
+	Check func(text, targetLanguage, translated string) error
+	// OnFallback 某个提供商失败、即将尝试下一个时调用
+	OnFallback func(provider string, err error)
+}
+
+// attributedTranslator 能报告实际产生译文的提供商的客户端
+type attributedTranslator interface {
+	TranslateAttributed(text, targetLanguage, userPrompt string) (translated, provider string, err error)
+}
+
+// NewFallbackClient 创建备用链客户端
+func NewFallbackClient(providers ...FallbackProvider) *FallbackClient {
+	return &FallbackClient{Providers: providers}
+}
+
+// Translate 依次尝试各提供商翻译文本
+func (c *FallbackClient) Translate(text, targetLanguage, userPrompt string) (string, error) {
+	translated, _, err := c.TranslateAttributed(text, targetLanguage, userPrompt)
+	return translated, err
+}
+
+// TranslateAttributed 依次尝试各提供商翻译文本，同时返回产生译文的提供商
+func (c *FallbackClient) TranslateAttributed(text, targetLanguage, userPrompt string) (string, string, error) {
+	return c.translate(text, targetLanguage, userPrompt, c.Check)
+}
+
+// TranslateStructured 依次尝试各提供商处理 etrans 自己构造的结构化请求（批量翻译、术语提取的 JSON 数组）
+// 结构化请求的结果由调用方解析和校验，不做 Check 的逐段质量检查
+func (c *FallbackClient) TranslateStructured(text, targetLanguage, userPrompt string) (string, string, error) {
+	return c.translate(text, targetLanguage, userPrompt, nil)
+}
+
+// translate 依次尝试各提供商，check 为空时不校验输出
+func (c *FallbackClient) translate(text, targetLanguage, userPrompt string, check func(text, targetLanguage, translated string) error) (string, string, error) {
+	var errs []error
+	var rejected, rejectedBy string
+	for i, provider := range c.Providers {
+		translated, err := try(provider, check, text, targetLanguage, userPrompt)
+		if err == nil {
+			return translated, provider.Name, nil
+		}
+		if translated != "" && rejectedBy == "" {
+			rejected, rejectedBy = translated, provider.Name
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name, err))
+		if i < len(c.Providers)-1 && c.OnFallback != nil {
+			c.OnFallback(provider.Name, err)
+		}
+	}
+	if len(errs) == 0 {
+		return "", "", fmt.Errorf("no provider configured")
+	}
+	if rejectedBy != "" {
+		return rejected, rejectedBy, nil
+	}
+	return "", "", fmt.Errorf("all providers failed: %w", errors.Join(errs...))
+}
+
+// try 使用单个提供商翻译并校验结果，未通过校验时同时返回译文和错误
+func try(provider FallbackProvider, check func(text, targetLanguage, translated string) error, text, targetLanguage, userPrompt string) (string, error) {
+	translated, err := provider.Client.Translate(text, targetLanguage, userPrompt)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(translated) == "" {
+		return "", ErrEmptyTranslation
+	}
+	if check != nil {
+		if err := check(text, targetLanguage, translated); err != nil {
+			return translated, err
+		}
+	}
+	return translated, nil
+}
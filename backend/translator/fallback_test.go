@@ -0,0 +1,128 @@
+package translator
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// staticClient 总是返回固定的结果
+type staticClient struct {
+	result string
+	err    error
+	calls  int
+}
+
+func (c *staticClient) Translate(text, targetLanguage, userPrompt string) (string, error) {
+	c.calls++
+	return c.result, c.err
+}
+
+func TestFallbackClient_FallsThrough(t *testing.T) {
+	tests := []struct {
+		name    string
+		primary *staticClient
+		check   func(text, targetLanguage, translated string) error
+	}{
+		{"error", &staticClient{err: &APIError{StatusCode: http.StatusInternalServerError}}, nil},
+		{"refusal", &staticClient{err: fmt.Errorf("%w: finish_reason=content_filter", ErrContentBlocked)}, nil},
+		{"empty output", &staticClient{result: "  "}, nil},
+		{"failed check", &staticClient{result: "Hello"}, func(text, targetLanguage, translated string) error {
+			if text == translated {
+				return errors.New("unchanged")
+			}
+			return nil
+		}},
+	}
+
+	for _, tt := range tests {
+		secondary := &staticClient{result: "你好"}
+		client := NewFallbackClient(
+			FallbackProvider{Name: "openai/cheap", Client: tt.primary},
+			FallbackProvider{Name: "claude/strong", Client: secondary},
+		)
+		client.Check = tt.check
+
+		translated, provider, err := client.TranslateAttributed("Hello", "Chinese", "")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if translated != "你好" || provider != "claude/strong" {
+			t.Errorf("%s: expected '你好' from claude/strong, got '%s' from %s", tt.name, translated, provider)
+		}
+		if tt.primary.calls != 1 || secondary.calls != 1 {
+			t.Errorf("%s: expected one call per provider, got %d and %d", tt.name, tt.primary.calls, secondary.calls)
+		}
+	}
+}
+
+func TestFallbackClient_AllFailed(t *testing.T) {
+	client := NewFallbackClient(
+		FallbackProvider{Name: "a", Client: &staticClient{err: errors.New("boom")}},
+		FallbackProvider{Name: "b", Client: &staticClient{err: ErrContentBlocked}},
+	)
+	_, err := client.Translate("Hello", "Chinese", "")
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if !errors.Is(err, ErrContentBlocked) {
+		t.Errorf("Expected joined error to wrap ErrContentBlocked, got %v", err)
+	}
+}
+
+func TestDocumentTranslator_RecordsProvider(t *testing.T) {
+	cache := newTestCache(t)
+	dt := &DocumentTranslator{
+		Client: NewFallbackClient(
+			FallbackProvider{Name: "a", Client: &staticClient{err: errors.New("boom")}},
+			FallbackProvider{Name: "b", Client: &staticClient{result: "你好"}},
+		),
+		Cache:    cache,
+		Provider: "a>b",
+	}
+
+	results := dt.translateGroup([]string{"Hello"}, "Chinese", "")
+	if results["Hello"].Provider != "b" {
+		t.Errorf("Expected provider 'b', got %+v", results["Hello"])
+	}
+
+	if _, err := dt.TranslateText("Hi", "Chinese", ""); err != nil {
+		t.Fatalf("TranslateText failed: %v", err)
+	}
+	entry, ok := cache.GetEntry(CacheKey("Hi", "Chinese", "", "a>b"))
+	if !ok || entry.Provider != "b" {
+		t.Errorf("Expected cache entry from provider 'b', got %+v", entry)
+	}
+	if _, ok := cache.Get(CacheKey("Hi", "Chinese", "", "a")); ok {
+		t.Error("Expected cache to be keyed on the provider chain")
+	}
+}
+
+func TestLLMClient_Translate_ContentFilter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":""},"finish_reason":"content_filter"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewLLMClient("test-api-key", server.URL, "gpt-3.5-turbo")
+	if _, err := client.Translate("Hello", "Chinese", ""); !errors.Is(err, ErrContentBlocked) {
+		t.Errorf("Expected ErrContentBlocked, got %v", err)
+	}
+}
+
+func TestFallbackClient_AllRejectedReturnsFirstOutput(t *testing.T) {
+	client := NewFallbackClient(
+		FallbackProvider{Name: "a", Client: &staticClient{result: "first"}},
+		FallbackProvider{Name: "b", Client: &staticClient{result: "second"}},
+	)
+	client.Check = func(text, targetLanguage, translated string) error { return errors.New("rejected") }
+
+	translated, provider, err := client.TranslateAttributed("Hello", "Chinese", "")
+	if err != nil || translated != "first" || provider != "a" {
+		t.Errorf("Expected rejected output 'first' from a, got '%s' from %s, %v", translated, provider, err)
+	}
+}
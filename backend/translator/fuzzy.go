@@ -0,0 +1,215 @@
+package translator
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxFuzzyCandidates 按共享 n-gram 数取前若干个候选原文，再逐个计算编辑距离
+const maxFuzzyCandidates = 50
+
+// referenceInstruction 模糊匹配参考译文的说明，放在系统提示词中
+const referenceInstruction = `Between <reference> and </reference> are earlier translations of passages very similar to the text you are given (similarity in the "match" attribute).
+Adapt the reference translation: keep its wording where the texts agree and change only what differs. Translate only the text in the user message; do NOT output the reference itself.`
+
+// TMMatch 翻译记忆库的模糊匹配结果
+type TMMatch struct {
+	TMUnit
+	// Score 原文相似度（0~1），1 表示规范化后完全相同
+	Score float64
+}
+
+// FuzzyLookup 查找与原文最相似、相似度不低于 threshold 的条目
+// 相似度为 1 - 词级编辑距离 / 较长一方的词数；先用词 bigram 索引筛选候选，避免遍历整个记忆库
+func (tm *TranslationMemory) FuzzyLookup(source, sourceLang, targetLang string, threshold float64) (TMMatch, bool) {
+	if tm == nil || threshold <= 0 {
+		return TMMatch{}, false
+	}
+	tokens := matchTokens(source)
+	if len(tokens) == 0 {
+		return TMMatch{}, false
+	}
+
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	sourceLang = NormalizeLanguage(sourceLang)
+	targetLang = NormalizeLanguage(targetLang)
+
+	// 统计各原文与 source 共享的 n-gram 数
+	shared := make(map[string]int)
+	for _, gram := range tokenGrams(tokens) {
+		for key := range tm.index[gram] {
+			shared[key]++
+		}
+	}
+	keys := make([]string, 0, len(shared))
+	for key := range shared {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if shared[keys[i]] != shared[keys[j]] {
+			return shared[keys[i]] > shared[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > maxFuzzyCandidates {
+		keys = keys[:maxFuzzyCandidates]
+	}
+
+	var best TMMatch
+	found := false
+	for _, key := range keys {
+		candidate := matchTokens(key)
+		// 词数差距过大时不可能达到阈值
+		if lengthBound(len(tokens), len(candidate)) < threshold {
+			continue
+		}
+		score := tokenSimilarity(tokens, candidate)
+		if score < threshold {
+			continue
+		}
+		for _, u := range tm.units[key] {
+			if !sameLanguage(u.TargetLang, targetLang) {
+				continue
+			}
+			if sourceLang != "" && u.SourceLang != "" && !sameLanguage(u.SourceLang, sourceLang) {
+				continue
+			}
+			better := !found || score > best.Score ||
+				(score == best.Score && u.Origin == TMOriginImport && best.Origin != TMOriginImport)
+			if better {
+				best = TMMatch{TMUnit: *u, Score: score}
+				found = true
+			}
+		}
+	}
+	return best, found
+}
+
+// indexKey 把原文的 n-gram 加入索引，调用方需持有写锁
+func (tm *TranslationMemory) indexKey(key string) {
+	for _, gram := range tokenGrams(matchTokens(key)) {
+		keys := tm.index[gram]
+		if keys == nil {
+			keys = make(map[string]struct{})
+			tm.index[gram] = keys
+		}
+		keys[key] = struct{}{}
+	}
+}
+
+// matchTokens 把文本切分为用于比较的词：连续的字母数字为一个词，CJK 字符和标点各自为一个词，忽略大小写
+func matchTokens(text string) []string {
+	var tokens []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+		case isCJK(r) || !(unicode.IsLetter(r) || unicode.IsDigit(r)):
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// tokenGrams 返回去重后的词 bigram，只有一个词时返回该词
+func tokenGrams(tokens []string) []string {
+	if len(tokens) == 1 {
+		return tokens
+	}
+	seen := make(map[string]bool)
+	var grams []string
+	for i := 0; i+1 < len(tokens); i++ {
+		gram := tokens[i] + "\x00" + tokens[i+1]
+		if !seen[gram] {
+			seen[gram] = true
+			grams = append(grams, gram)
+		}
+	}
+	return grams
+}
+
+// lengthBound 返回两段词数分别为 a、b 的文本可能达到的最高相似度
+func lengthBound(a, b int) float64 {
+	if a < b {
+		a, b = b, a
+	}
+	if a == 0 {
+		return 1
+	}
+	return float64(b) / float64(a)
+}
+
+// tokenSimilarity 返回 1 - 词级编辑距离 / 较长一方的词数
+func tokenSimilarity(a, b []string) float64 {
+	longest := len(a)
+	if len(b) > longest {
+		longest = len(b)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(tokenEditDistance(a, b))/float64(longest)
+}
+
+// tokenEditDistance 计算两个词序列的 Levenshtein 距离
+func tokenEditDistance(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// referenceBlock 返回 texts 的模糊匹配参考译文的提示词片段，没有时返回空
+func (dt *DocumentTranslator) referenceBlock(texts ...string) string {
+	var b strings.Builder
+	for _, text := range texts {
+		match, ok := dt.fuzzyMatches[text]
+		if !ok {
+			continue
+		}
+		if b.Len() == 0 {
+			b.WriteString(referenceInstruction)
+		}
+		b.WriteString("\n<reference match=\"")
+		b.WriteString(formatScore(match.Score))
+		b.WriteString("\">\n<source>")
+		b.WriteString(match.Source)
+		b.WriteString("</source>\n<translation>")
+		b.WriteString(match.Target)
+		b.WriteString("</translation>\n</reference>")
+	}
+	return b.String()
+}
+
+// formatScore 把相似度格式化为百分比，如 0.874 -> "87%"
+func formatScore(score float64) string {
+	return strconv.Itoa(int(score*100)) + "%"
+}
@@ -0,0 +1,89 @@
+package translator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTokenSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"The quick brown fox.", "the quick brown fox.", 1},
+		{"The quick brown fox.", "The quick red fox.", 0.8},
+		{"The quick brown fox.", "A slow dog.", 0.2}, // 只有句号相同
+		{"他走进了房间。", "她走进了房间。", 6.0 / 7},
+	}
+	for _, tt := range tests {
+		got := tokenSimilarity(matchTokens(tt.a), matchTokens(tt.b))
+		if fmt.Sprintf("%.3f", got) != fmt.Sprintf("%.3f", tt.want) {
+			t.Errorf("tokenSimilarity(%q, %q) = %.3f, want %.3f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFuzzyLookup(t *testing.T) {
+	tm := NewTranslationMemory()
+	tm.Add(TMUnit{Source: "It was a bright cold day in April, and the clocks were striking thirteen.", Target: "四月里一个晴朗寒冷的日子，时钟敲了十三下。", SourceLang: "en", TargetLang: "zh-cn"})
+	tm.Add(TMUnit{Source: "Something completely different happened on that day.", Target: "那天发生了完全不同的事。", SourceLang: "en", TargetLang: "zh-cn"})
+	for i := 0; i < 100; i++ {
+		tm.Add(TMUnit{Source: fmt.Sprintf("Filler sentence number %d.", i), Target: "填充", SourceLang: "en", TargetLang: "zh-cn"})
+	}
+
+	source := "It was a bright cold day in May, and the clocks were striking thirteen."
+	match, ok := tm.FuzzyLookup(source, "en", "zh-cn", 0.8)
+	if !ok || !strings.HasPrefix(match.Target, "四月") {
+		t.Fatalf("Expected fuzzy match, got %+v ok=%v", match, ok)
+	}
+	if match.Score < 0.9 || match.Score >= 1 {
+		t.Errorf("Expected score in [0.9, 1), got %.3f", match.Score)
+	}
+
+	if _, ok := tm.FuzzyLookup(source, "en", "zh-cn", 0.99); ok {
+		t.Errorf("Expected no match above threshold")
+	}
+	if _, ok := tm.FuzzyLookup(source, "en", "ja", 0.5); ok {
+		t.Errorf("Expected no match for other target language")
+	}
+	if _, ok := tm.FuzzyLookup(source, "en", "zh-cn", 0); ok {
+		t.Errorf("Expected fuzzy matching disabled with threshold 0")
+	}
+}
+
+func TestTranslateEPUB_FuzzyReference(t *testing.T) {
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  testOPF,
+		"OEBPS/text/a.xhtml": testChapter("Nothing similar here at all."),
+		"OEBPS/text/b.xhtml": testChapter("The second edition adds one new word to this sentence."),
+	})
+
+	memory := NewTranslationMemory()
+	memory.Add(TMUnit{Source: "The first edition adds one new word to this sentence.", Target: "第一版在这句话中加了一个新词。", SourceLang: "en", TargetLang: "zh-cn", Origin: TMOriginImport})
+
+	client := &recordingClient{}
+	cache := newTestCache(t)
+	dt := &DocumentTranslator{Client: client, Cache: cache, Memory: memory, Provider: "test/model", Options: TranslateOptions{FuzzyThreshold: 0.75}}
+	output := filepath.Join(t.TempDir(), "out.epub")
+	if _, err := dt.TranslateEPUB("fuzzy", path, output, "zh-CN", "", "bilingual", nil, nil); err != nil {
+		t.Fatalf("TranslateEPUB failed: %v", err)
+	}
+
+	prompt := client.prompts["The second edition adds one new word to this sentence."]
+	if !strings.Contains(prompt, `<reference match="90%">`) || !strings.Contains(prompt, "<translation>第一版在这句话中加了一个新词。</translation>") {
+		t.Errorf("Expected reference translation in prompt, got %q", prompt)
+	}
+	if prompt := client.prompts["Nothing similar here at all."]; strings.Contains(prompt, "<reference") {
+		t.Errorf("Expected no reference for unmatched block, got %q", prompt)
+	}
+
+	matches := dt.SegmentMatches()
+	if score := matches["The second edition adds one new word to this sentence."]; fmt.Sprintf("%.2f", score) != "0.91" {
+		t.Errorf("Expected match score 0.91, got %v", matches)
+	}
+	if saved, err := cache.LoadMatchMap("fuzzy"); err != nil || len(saved) != 1 {
+		t.Errorf("Expected saved match map, got %v, %v", saved, err)
+	}
+}
@@ -0,0 +1,245 @@
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Gemini generateContent API Request/Response structures
+type GeminiPart struct {
+	Text string `json:"text"`
+}
+
+type GeminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []GeminiPart `json:"parts"`
+}
+
+type GeminiGenerationConfig struct {
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"topP,omitempty"`
+	Seed            *int     `json:"seed,omitempty"`
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
+}
+
+type GeminiGenerateRequest struct {
+	Contents          []GeminiContent         `json:"contents"`
+	SystemInstruction *GeminiContent          `json:"systemInstruction,omitempty"`
+	GenerationConfig  *GeminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type GeminiSafetyRating struct {
+	Category    string `json:"category"`
+	Probability string `json:"probability"`
+	Blocked     bool   `json:"blocked,omitempty"`
+}
+
+type GeminiGenerateResponse struct {
+	Candidates []struct {
+		Content       GeminiContent        `json:"content"`
+		FinishReason  string               `json:"finishReason"`
+		SafetyRatings []GeminiSafetyRating `json:"safetyRatings"`
+	} `json:"candidates"`
+	PromptFeedback *struct {
+		BlockReason   string               `json:"blockReason"`
+		SafetyRatings []GeminiSafetyRating `json:"safetyRatings"`
+	} `json:"promptFeedback"`
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+// GeminiBlockedError Gemini 拒绝处理请求（promptFeedback.blockReason 或候选结果被安全过滤）
+type GeminiBlockedError struct {
+	BlockReason   string
+	FinishReason  string
+	SafetyRatings []GeminiSafetyRating
+}
+
+func (e *GeminiBlockedError) Error() string {
+	if e.BlockReason != "" {
+		return fmt.Sprintf("gemini blocked prompt: %s", e.BlockReason)
+	}
+	return fmt.Sprintf("gemini blocked response: %s", e.FinishReason)
+}
+
+// Unwrap 使 errors.Is(err, ErrContentBlocked) 成立
+func (e *GeminiBlockedError) Unwrap() error {
+	return ErrContentBlocked
+}
+
+// geminiBlockedFinishReasons 表示候选结果被过滤的 finishReason
+var geminiBlockedFinishReasons = map[string]bool{
+	"SAFETY":             true,
+	"RECITATION":         true,
+	"BLOCKLIST":          true,
+	"PROHIBITED_CONTENT": true,
+	"SPII":               true,
+}
+
+// GeminiClient Google Gemini 翻译客户端
+type GeminiClient struct {
+	APIKey           string
+	BaseURL          string
+	Model            string
+	GenerationConfig *GeminiGenerationConfig
+	// KeyInQuery 为 true 时通过 ?key= 传递 API Key，否则使用 x-goog-api-key 请求头
+	KeyInQuery bool
+	Client     *http.Client
+	Timeout    time.Duration
+}
+
+// NewGeminiClient 创建新的 Gemini 客户端
+// Extra["auth_mode"] 为 "query" 时将 API Key 放在查询参数中
+func NewGeminiClient(config ProviderConfig) *GeminiClient {
+	authMode, _ := config.ExtraString("auth_mode")
+
+	// 将 ProviderConfig 映射为 generationConfig，全部未设置时省略该字段
+	genConfig := &GeminiGenerationConfig{
+		Temperature:     config.Temperature,
+		MaxOutputTokens: config.MaxTokens,
+	}
+	if topP, ok := config.ExtraFloat("top_p"); ok {
+		genConfig.TopP = &topP
+	}
+	if seed, ok := config.ExtraInt("seed"); ok {
+		genConfig.Seed = &seed
+	}
+	if *genConfig == (GeminiGenerationConfig{}) {
+		genConfig = nil
+	}
+
+	timeout := config.HTTPTimeout(60 * time.Second)
+	return &GeminiClient{
+		APIKey:           config.APIKey,
+		BaseURL:          config.APIURL,
+		Model:            config.Model,
+		GenerationConfig: genConfig,
+		KeyInQuery:       authMode == "query",
+		Client: &http.Client{
+			Timeout: timeout,
+		},
+		Timeout: timeout,
+	}
+}
+
+// endpoint 构造 generateContent 请求地址
+func (c *GeminiClient) endpoint() (string, error) {
+	// 用户可能给出完整的 .../models/gemini-pro:generateContent 地址，
+	// 也可能只给出 https://generativelanguage.googleapis.com/v1beta
+	apiURL := strings.TrimSuffix(c.BaseURL, "/")
+	if !strings.Contains(apiURL, ":generateContent") {
+		if !strings.HasSuffix(apiURL, "/models") {
+			apiURL += "/models"
+		}
+		apiURL += "/" + c.Model + ":generateContent"
+	}
+
+	if !c.KeyInQuery || c.APIKey == "" {
+		return apiURL, nil
+	}
+
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return "", fmt.Errorf("parse api url failed: %w", err)
+	}
+	q := u.Query()
+	q.Set("key", c.APIKey)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Translate 使用 Gemini generateContent API 翻译文本
+func (c *GeminiClient) Translate(text, targetLanguage, userPrompt string) (string, error) {
+	reqBody := GeminiGenerateRequest{
+		Contents: []GeminiContent{
+			{Role: "user", Parts: []GeminiPart{{Text: text}}},
+		},
+		SystemInstruction: &GeminiContent{
+			Parts: []GeminiPart{{Text: buildSystemPrompt(targetLanguage, userPrompt)}},
+		},
+		GenerationConfig: c.GenerationConfig,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("marshal request failed: %w", err)
+	}
+
+	apiURL, err := c.endpoint()
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("create request failed: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" && !c.KeyInQuery {
+		req.Header.Set("x-goog-api-key", c.APIKey)
+	}
+
+	// 发送请求
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("send request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newAPIError(resp)
+	}
+
+	// 解析响应
+	var genResp GeminiGenerateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&genResp); err != nil {
+		return "", fmt.Errorf("decode response failed: %w", err)
+	}
+
+	if genResp.Error.Message != "" {
+		return "", fmt.Errorf("API error: %s", genResp.Error.Message)
+	}
+
+	// 整个提示词被拦截时不会返回任何候选结果
+	if genResp.PromptFeedback != nil && genResp.PromptFeedback.BlockReason != "" {
+		return "", &GeminiBlockedError{
+			BlockReason:   genResp.PromptFeedback.BlockReason,
+			SafetyRatings: genResp.PromptFeedback.SafetyRatings,
+		}
+	}
+
+	if len(genResp.Candidates) == 0 {
+		return "", fmt.Errorf("no translation returned")
+	}
+
+	candidate := genResp.Candidates[0]
+	var result strings.Builder
+	for _, part := range candidate.Content.Parts {
+		result.WriteString(part.Text)
+	}
+
+	// 生成中途被拦截时可能已有部分文本，同样视为被拦截
+	if geminiBlockedFinishReasons[candidate.FinishReason] {
+		return "", &GeminiBlockedError{
+			FinishReason:  candidate.FinishReason,
+			SafetyRatings: candidate.SafetyRatings,
+		}
+	}
+	if result.Len() == 0 {
+		return "", fmt.Errorf("no translation returned")
+	}
+	if candidate.FinishReason == "MAX_TOKENS" {
+		return "", fmt.Errorf("%w: finishReason=%s", ErrIncompleteOutput, candidate.FinishReason)
+	}
+
+	return strings.TrimSpace(result.String()), nil
+}
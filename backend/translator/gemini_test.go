@@ -0,0 +1,120 @@
+package translator
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGeminiClient_Translate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1beta/models/gemini-pro:generateContent" {
+			t.Errorf("Expected path '/v1beta/models/gemini-pro:generateContent', got %s", r.URL.Path)
+		}
+		if r.Header.Get("x-goog-api-key") != "test-api-key" {
+			t.Errorf("Expected x-goog-api-key header 'test-api-key', got %s", r.Header.Get("x-goog-api-key"))
+		}
+		if r.URL.Query().Get("key") != "" {
+			t.Errorf("Expected no key query parameter, got %s", r.URL.Query().Get("key"))
+		}
+
+		var req GeminiGenerateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+
+		if len(req.Contents) != 1 || req.Contents[0].Parts[0].Text != "Hello" {
+			t.Errorf("Expected single content 'Hello', got %+v", req.Contents)
+		}
+		if req.SystemInstruction == nil || len(req.SystemInstruction.Parts) == 0 {
+			t.Error("Expected systemInstruction to be set")
+		}
+		if req.GenerationConfig == nil || req.GenerationConfig.Temperature == nil || *req.GenerationConfig.Temperature != 0.5 {
+			t.Errorf("Expected generationConfig.temperature 0.5, got %+v", req.GenerationConfig)
+		}
+		if req.GenerationConfig.MaxOutputTokens != 1000 {
+			t.Errorf("Expected generationConfig.maxOutputTokens 1000, got %d", req.GenerationConfig.MaxOutputTokens)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"你好"}]},"finishReason":"STOP"}]}`))
+	}))
+	defer server.Close()
+
+	temperature := 0.5
+	client := NewGeminiClient(ProviderConfig{
+		Type:        ProviderGemini,
+		APIKey:      "test-api-key",
+		APIURL:      server.URL + "/v1beta",
+		Model:       "gemini-pro",
+		Temperature: &temperature,
+		MaxTokens:   1000,
+	})
+
+	result, err := client.Translate("Hello", "Chinese", "")
+	if err != nil {
+		t.Fatalf("Translate failed: %v", err)
+	}
+	if result != "你好" {
+		t.Errorf("Expected translation '你好', got '%s'", result)
+	}
+}
+
+func TestGeminiClient_Translate_Blocked(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") != "test-api-key" {
+			t.Errorf("Expected key query parameter 'test-api-key', got %s", r.URL.Query().Get("key"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"promptFeedback":{"blockReason":"SAFETY","safetyRatings":[{"category":"HARM_CATEGORY_HARASSMENT","probability":"HIGH","blocked":true}]}}`))
+	}))
+	defer server.Close()
+
+	client := NewGeminiClient(ProviderConfig{
+		APIKey: "test-api-key",
+		APIURL: server.URL + "/v1/models/gemini-pro:generateContent",
+		Model:  "gemini-pro",
+		Extra:  map[string]interface{}{"auth_mode": "query"},
+	})
+
+	_, err := client.Translate("Hello", "Chinese", "")
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	var blocked *GeminiBlockedError
+	if !errors.As(err, &blocked) {
+		t.Fatalf("Expected GeminiBlockedError, got %T: %v", err, err)
+	}
+	if blocked.BlockReason != "SAFETY" {
+		t.Errorf("Expected block reason 'SAFETY', got '%s'", blocked.BlockReason)
+	}
+	if !errors.Is(err, ErrContentBlocked) {
+		t.Error("Expected errors.Is(err, ErrContentBlocked) to be true")
+	}
+}
+
+func TestGeminiClient_Translate_BlockedPartial(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"candidates":[{"content":{"parts":[{"text":"部分译文"}],"role":"model"},"finishReason":"RECITATION"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewGeminiClient(ProviderConfig{
+		APIKey: "test-api-key",
+		APIURL: server.URL + "/v1/models/gemini-pro:generateContent",
+		Model:  "gemini-pro",
+	})
+
+	translated, err := client.Translate("Hello", "Chinese", "")
+	var blocked *GeminiBlockedError
+	if !errors.As(err, &blocked) {
+		t.Fatalf("Expected GeminiBlockedError for a partial blocked response, got %q, %v", translated, err)
+	}
+	if blocked.FinishReason != "RECITATION" || !errors.Is(err, ErrContentBlocked) {
+		t.Errorf("Unexpected blocked error: %+v", blocked)
+	}
+}
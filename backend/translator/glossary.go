@@ -0,0 +1,223 @@
+package translator
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GlossaryEntry 术语表条目
+type GlossaryEntry struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	// CaseSensitive 匹配原文时区分大小写
+	CaseSensitive bool `json:"caseSensitive,omitempty"`
+	// DoNotTranslate 保留原文不翻译（此时忽略 Target）
+	DoNotTranslate bool `json:"doNotTranslate,omitempty"`
+}
+
+// Expected 返回译文中应出现的写法
+func (e GlossaryEntry) Expected() string {
+	if e.DoNotTranslate || e.Target == "" {
+		return e.Source
+	}
+	return e.Target
+}
+
+// Glossary 术语表
+type Glossary struct {
+	Entries []GlossaryEntry
+}
+
+// GlossaryViolation 译文未按术语表翻译的记录
+type GlossaryViolation struct {
+	Term        string `json:"term"`
+	Expected    string `json:"expected"`
+	Source      string `json:"source"`
+	Translation string `json:"translation"`
+}
+
+// ParseGlossary 解析 CSV/TSV 术语表
+// 每行依次为：原文术语、目标术语、是否区分大小写、是否保留原文（后两列可省略，取值 true/yes/1）
+// 第一行为 source,target 之类的表头时会被跳过；.tsv/.tab 文件或首行含制表符时按 TSV 解析
+func ParseGlossary(r io.Reader, filename string) (*Glossary, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("读取术语表失败: %w", err)
+	}
+	content := strings.TrimPrefix(string(data), "\ufeff")
+
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	ext := strings.ToLower(filepath.Ext(filename))
+	firstLine, _, _ := strings.Cut(content, "\n")
+	if ext == ".tsv" || ext == ".tab" || strings.Contains(firstLine, "\t") {
+		reader.Comma = '\t'
+		reader.LazyQuotes = true
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("解析术语表失败: %w", err)
+	}
+
+	glossary := &Glossary{}
+	for i, record := range records {
+		if len(record) == 0 || strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		if i == 0 && isGlossaryHeader(record) {
+			continue
+		}
+
+		entry := GlossaryEntry{Source: strings.TrimSpace(record[0])}
+		if len(record) > 1 {
+			entry.Target = strings.TrimSpace(record[1])
+		}
+		if len(record) > 2 {
+			entry.CaseSensitive = parseGlossaryFlag(record[2])
+		}
+		if len(record) > 3 {
+			entry.DoNotTranslate = parseGlossaryFlag(record[3])
+		}
+		if entry.Target == "" && !entry.DoNotTranslate {
+			return nil, fmt.Errorf("术语表第 %d 行缺少目标术语: %s", i+1, entry.Source)
+		}
+		glossary.Entries = append(glossary.Entries, entry)
+	}
+	return glossary, nil
+}
+
+// isGlossaryHeader 判断是否为表头行
+func isGlossaryHeader(record []string) bool {
+	first := strings.ToLower(strings.TrimSpace(record[0]))
+	return first == "source" || first == "term" || first == "原文" || first == "术语"
+}
+
+// parseGlossaryFlag 解析 true/yes/1/y/x/是 等布尔标记
+func parseGlossaryFlag(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes", "y", "1", "x", "是":
+		return true
+	}
+	return false
+}
+
+// Match 返回文本中出现的术语
+func (g *Glossary) Match(text string) []GlossaryEntry {
+	if g == nil {
+		return nil
+	}
+	var matched []GlossaryEntry
+	for _, entry := range g.Entries {
+		if containsTerm(text, entry.Source, entry.CaseSensitive) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
+
+// Prompt 生成注入提示词的术语说明，只包含 texts 中出现的术语，没有时返回空字符串
+func (g *Glossary) Prompt(texts ...string) string {
+	if g == nil {
+		return ""
+	}
+
+	var lines []string
+	seen := make(map[int]bool)
+	for _, text := range texts {
+		for i, entry := range g.Entries {
+			if seen[i] || !containsTerm(text, entry.Source, entry.CaseSensitive) {
+				continue
+			}
+			seen[i] = true
+			if entry.DoNotTranslate {
+				lines = append(lines, fmt.Sprintf("- %s → keep as \"%s\" (do not translate)", entry.Source, entry.Source))
+			} else {
+				lines = append(lines, fmt.Sprintf("- %s → %s", entry.Source, entry.Target))
+			}
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "Glossary: translate these terms exactly as listed, every time they appear:\n" + strings.Join(lines, "\n")
+}
+
+// Check 检查译文是否使用了术语表中的译法
+func (g *Glossary) Check(source, translated string) []GlossaryViolation {
+	var violations []GlossaryViolation
+	for _, entry := range g.Match(source) {
+		expected := entry.Expected()
+		if !containsTerm(translated, expected, entry.CaseSensitive) {
+			violations = append(violations, GlossaryViolation{
+				Term:        entry.Source,
+				Expected:    expected,
+				Source:      source,
+				Translation: translated,
+			})
+		}
+	}
+	return violations
+}
+
+// containsTerm 判断文本中是否包含术语
+// 以字母或数字开头/结尾的术语要求在单词边界上，避免 Ann 匹配到 Annual；CJK 文字没有单词边界，直接按子串匹配
+func containsTerm(text, term string, caseSensitive bool) bool {
+	if term == "" {
+		return false
+	}
+	if !caseSensitive {
+		text = strings.ToLower(text)
+		term = strings.ToLower(term)
+	}
+
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], term)
+		if i < 0 {
+			return false
+		}
+		start := offset + i
+		end := start + len(term)
+		if onWordBoundary(text, start, end, term) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+// onWordBoundary 判断 text[start:end] 两侧是否为单词边界
+func onWordBoundary(text string, start, end int, term string) bool {
+	first, _ := utf8.DecodeRuneInString(term)
+	last, _ := utf8.DecodeLastRuneInString(term)
+
+	if isWordRune(first) && !isCJK(first) && start > 0 {
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		if isWordRune(before) && !isCJK(before) {
+			return false
+		}
+	}
+	if isWordRune(last) && !isCJK(last) && end < len(text) {
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if isWordRune(after) && !isCJK(after) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// glossaryPrompt 把 texts 中出现的术语追加到用户提示词之后
+// 只注入相关术语，因此术语表的修改只会使包含这些术语的译文缓存失效
+func (dt *DocumentTranslator) glossaryPrompt(targetLanguage, userPrompt string, texts ...string) string {
+	return dt.composePrompt(targetLanguage, userPrompt, promptParts{Glossary: dt.Options.Glossary.Prompt(texts...)})
+}
@@ -0,0 +1,96 @@
+package translator
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseGlossary(t *testing.T) {
+	csvContent := "source,target,case_sensitive,do_not_translate\nFrodo,佛罗多\n\"Mount Doom\",末日火山,yes\nShire,,,true\n"
+	glossary, err := ParseGlossary(strings.NewReader(csvContent), "terms.csv")
+	if err != nil {
+		t.Fatalf("ParseGlossary failed: %v", err)
+	}
+	if len(glossary.Entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %+v", glossary.Entries)
+	}
+	if e := glossary.Entries[1]; e.Source != "Mount Doom" || e.Target != "末日火山" || !e.CaseSensitive {
+		t.Errorf("Unexpected entry: %+v", e)
+	}
+	if e := glossary.Entries[2]; !e.DoNotTranslate || e.Expected() != "Shire" {
+		t.Errorf("Unexpected entry: %+v", e)
+	}
+
+	tsv, err := ParseGlossary(strings.NewReader("Gandalf\t甘道夫\n"), "terms.txt")
+	if err != nil || len(tsv.Entries) != 1 || tsv.Entries[0].Target != "甘道夫" {
+		t.Errorf("Expected TSV to be detected, got %+v, %v", tsv, err)
+	}
+
+	if _, err := ParseGlossary(strings.NewReader("Sam\n"), "terms.csv"); err == nil {
+		t.Error("Expected error for entry without target")
+	}
+}
+
+func TestContainsTerm(t *testing.T) {
+	tests := []struct {
+		text, term    string
+		caseSensitive bool
+		want          bool
+	}{
+		{"Ann went home.", "Ann", true, true},
+		{"The Annual report.", "Ann", false, false},
+		{"said ann.", "Ann", false, true},
+		{"said ann.", "Ann", true, false},
+		{"佛罗多走了", "佛罗多", true, true},
+	}
+	for _, tt := range tests {
+		if got := containsTerm(tt.text, tt.term, tt.caseSensitive); got != tt.want {
+			t.Errorf("containsTerm(%q, %q, %v) = %v, want %v", tt.text, tt.term, tt.caseSensitive, got, tt.want)
+		}
+	}
+}
+
+func TestTranslateEPUB_Glossary(t *testing.T) {
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  testOPF,
+		"OEBPS/text/a.xhtml": testChapter("Frodo left the Shire."),
+		"OEBPS/text/b.xhtml": testChapter("Nothing to see here."),
+	})
+
+	client := &recordingClient{}
+	cache := newTestCache(t)
+	dt := &DocumentTranslator{
+		Client: client,
+		Cache:  cache,
+		Options: TranslateOptions{Glossary: &Glossary{Entries: []GlossaryEntry{
+			{Source: "Frodo", Target: "佛罗多"},
+			{Source: "Gandalf", Target: "甘道夫"},
+		}}},
+	}
+
+	output := filepath.Join(t.TempDir(), "out.epub")
+	if _, err := dt.TranslateEPUB("glossary", path, output, "Chinese", "", "bilingual", nil, nil); err != nil {
+		t.Fatalf("TranslateEPUB failed: %v", err)
+	}
+
+	prompt := client.prompts["Frodo left the Shire."]
+	if !strings.Contains(prompt, "Frodo → 佛罗多") || strings.Contains(prompt, "Gandalf") {
+		t.Errorf("Expected only matching terms in prompt, got %q", prompt)
+	}
+	if prompt := client.prompts["Nothing to see here."]; prompt != "" {
+		t.Errorf("Expected no glossary for segment without terms, got %q", prompt)
+	}
+
+	// recordingClient 返回 pseudoTranslate(原文)，没有使用术语译法
+	violations := dt.GlossaryViolations()
+	if len(violations) != 1 || violations[0].Term != "Frodo" || violations[0].Expected != "佛罗多" {
+		t.Errorf("Unexpected violations: %+v", violations)
+	}
+
+	// 修改相关术语后缓存键改变
+	dt.Options.Glossary.Entries[0].Target = "弗罗多"
+	if _, ok := cache.Get(dt.cacheKey("Frodo left the Shire.", "Chinese", dt.glossaryPrompt("Chinese", "", "Frodo left the Shire."))); ok {
+		t.Error("Expected glossary change to invalidate the cached translation")
+	}
+}
@@ -0,0 +1,221 @@
+package translator
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// placeholderInstruction 文本块包含占位符时追加到提示词中的说明
+const placeholderInstruction = `The text contains numbered placeholder tags that stand for inline formatting, links, footnote markers and line breaks: paired tags like <x1>...</x1> and empty tags like <x2/>.
+Keep every placeholder exactly once and do not renumber them. Put the translation of the words inside a paired tag between the same pair of tags, and move tags only as far as the word order of the translation requires.`
+
+// placeholderRetryInstruction 译文丢失或改动了占位符、重新翻译时追加的说明
+const placeholderRetryInstruction = `Your previous answer lost or changed some placeholder tags. Return every <xN>...</xN> and <xN/> tag of the source exactly once.`
+
+// placeholderPattern 占位符标签 <x1>、</x1>、<x1/>，容忍模型在标签内加入的空白
+var placeholderPattern = regexp.MustCompile(`<\s*(/?)\s*x(\d+)\s*(/?)\s*>`)
+
+// placeholderKind 占位符标签的类型
+type placeholderKind int
+
+const (
+	placeholderOpen placeholderKind = iota
+	placeholderClose
+	placeholderEmpty
+)
+
+// placeholderTag 文本中的一个占位符标签
+type placeholderTag struct {
+	kind placeholderKind
+	n    int
+	// start、end 标签在文本中的位置
+	start, end int
+}
+
+// placeholderTags 按出现顺序返回文本中的占位符标签
+func placeholderTags(text string) []placeholderTag {
+	var tags []placeholderTag
+	for _, m := range placeholderPattern.FindAllStringSubmatchIndex(text, -1) {
+		n, err := strconv.Atoi(text[m[4]:m[5]])
+		if err != nil {
+			continue
+		}
+		tag := placeholderTag{kind: placeholderOpen, n: n, start: m[0], end: m[1]}
+		switch {
+		case m[3] > m[2]:
+			tag.kind = placeholderClose
+		case m[7] > m[6]:
+			tag.kind = placeholderEmpty
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// hasPlaceholders 判断文本是否包含占位符
+func hasPlaceholders(text string) bool {
+	return placeholderPattern.MatchString(text)
+}
+
+// stripPlaceholders 去掉文本中的占位符，用于语言检测、质量检查和退回纯文本
+func stripPlaceholders(text string) string {
+	if !hasPlaceholders(text) {
+		return text
+	}
+	return placeholderPattern.ReplaceAllString(text, "")
+}
+
+// samePlaceholders 判断译文是否恰好保留了原文中的占位符（顺序可以不同）
+// 拆分翻译的长文本块中成对的标签可能分在两部分，这里只比较标签本身，嵌套在插入时检查
+func samePlaceholders(source, translated string) bool {
+	type key struct {
+		kind placeholderKind
+		n    int
+	}
+	counts := make(map[key]int)
+	for _, tag := range placeholderTags(source) {
+		counts[key{tag.kind, tag.n}]++
+	}
+	for _, tag := range placeholderTags(translated) {
+		counts[key{tag.kind, tag.n}]--
+	}
+	for _, count := range counts {
+		if count != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// emptyPlaceholder 判断元素是否编码为 <xN/>：没有子节点的元素，以及内容不翻译的元素
+func emptyPlaceholder(n *html.Node) bool {
+	return n.FirstChild == nil || skippedElements[n.Data]
+}
+
+// validPlaceholders 检查译文中的占位符能否还原到 inline 对应的元素上：
+// 每个占位符恰好出现一次，类型与原元素一致，成对的标签正确嵌套
+func validPlaceholders(translation string, inline []*html.Node) bool {
+	used := make([]bool, len(inline))
+	var open []int
+	for _, tag := range placeholderTags(translation) {
+		if tag.n < 1 || tag.n > len(inline) {
+			return false
+		}
+		switch tag.kind {
+		case placeholderClose:
+			if len(open) == 0 || open[len(open)-1] != tag.n {
+				return false
+			}
+			open = open[:len(open)-1]
+			continue
+		case placeholderOpen:
+			open = append(open, tag.n)
+		}
+		if used[tag.n-1] || (tag.kind == placeholderEmpty) != emptyPlaceholder(inline[tag.n-1]) {
+			return false
+		}
+		used[tag.n-1] = true
+	}
+	if len(open) > 0 {
+		return false
+	}
+	for _, ok := range used {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// restoreInline 按译文中的占位符把文本块的内联元素还原到译文上，返回依次排列的节点
+// clone 为 false 时直接使用原元素（保留原始字节），为 true 时使用去掉 id 的副本（双语模式，原文保持不变）
+// 占位符与原文不一致时返回 false，不修改节点树
+func (seg textSegment) restoreInline(translation string, clone bool) ([]*html.Node, bool) {
+	if !validPlaceholders(translation, seg.inline) {
+		return nil, false
+	}
+
+	root := &html.Node{Type: html.DocumentNode}
+	stack := []*html.Node{root}
+	appendText := func(text string) {
+		if text != "" {
+			stack[len(stack)-1].AppendChild(&html.Node{Type: html.TextNode, Data: text})
+		}
+	}
+
+	pos := 0
+	for _, tag := range placeholderTags(translation) {
+		appendText(translation[pos:tag.start])
+		pos = tag.end
+		if tag.kind == placeholderClose {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		n := placeholderNode(seg.inline[tag.n-1], tag.kind == placeholderOpen, clone)
+		if n == nil {
+			continue
+		}
+		stack[len(stack)-1].AppendChild(n)
+		if tag.kind == placeholderOpen {
+			stack = append(stack, n)
+		}
+	}
+	appendText(translation[pos:])
+
+	var nodes []*html.Node
+	for n := root.FirstChild; n != nil; n = root.FirstChild {
+		root.RemoveChild(n)
+		nodes = append(nodes, n)
+	}
+	return nodes, true
+}
+
+// placeholderNode 返回占位符对应的节点：成对的占位符只保留元素本身，内容由译文填充；<xN/> 保留整个节点
+func placeholderNode(orig *html.Node, paired, clone bool) *html.Node {
+	if clone {
+		if !paired {
+			return cloneWithoutIDs(orig)
+		}
+		return &html.Node{Type: orig.Type, Data: orig.Data, DataAtom: orig.DataAtom, Namespace: orig.Namespace, Attr: withoutID(orig.Attr)}
+	}
+
+	if orig.Parent != nil {
+		orig.Parent.RemoveChild(orig)
+	}
+	if paired {
+		for c := orig.FirstChild; c != nil; c = orig.FirstChild {
+			orig.RemoveChild(c)
+		}
+	}
+	return orig
+}
+
+// cloneWithoutIDs 深拷贝节点，去掉 id 属性以免与原文重复；多余的结束标签不复制
+func cloneWithoutIDs(n *html.Node) *html.Node {
+	if n.Type == html.RawNode {
+		return nil
+	}
+	c := &html.Node{Type: n.Type, Data: n.Data, DataAtom: n.DataAtom, Namespace: n.Namespace, Attr: withoutID(n.Attr)}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if cc := cloneWithoutIDs(child); cc != nil {
+			c.AppendChild(cc)
+		}
+	}
+	return c
+}
+
+// withoutID 返回去掉 id 属性的属性列表
+func withoutID(attrs []html.Attribute) []html.Attribute {
+	var result []html.Attribute
+	for _, attr := range attrs {
+		if attr.Namespace == "" && strings.EqualFold(attr.Key, "id") {
+			continue
+		}
+		result = append(result, attr)
+	}
+	return result
+}
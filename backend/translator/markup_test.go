@@ -0,0 +1,158 @@
+package translator
+
+import (
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExtractTextBlocks_InlineMarkup(t *testing.T) {
+	body := `<p>Read <em>this</em> and <strong>that</strong>.<a id="r1" epub:type="noteref" href="#fn1"><sup>1</sup></a></p>
+<p><ruby>漢<rp>(</rp><rt>kan</rt><rp>)</rp></ruby>字の本</p>
+<p>Plain <!-- note --> text</p>
+<p>Split <em>across <span>entry</span> blocks</em> here</p>`
+	want := []string{
+		"Read <x1>this</x1> and <x2>that</x2>.<x3><x4>1</x4></x3>",
+		"<x1>漢<x2/><x3/><x4/></x1>字の本",
+		"Plain text",
+		// <em> 中有单独成块的 span，无法编码为占位符
+		"Split across",
+		"entry",
+		"blocks here",
+	}
+	if got := ExtractTextBlocks(body); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	source := "Read <x1>this</x1>.<x2/>"
+	tests := []struct {
+		translated string
+		same       bool
+	}{
+		{"读<x1>这个</x1>。<x2/>", true},
+		{"<x2/>读< x1 >这个</x1 >。", true},
+		{"读这个。<x2/>", false},
+		{"读<x1>这个</x1>。<x2/><x2/>", false},
+		{"读<x1>这个</x1>。<x2></x2>", false},
+	}
+	for _, tt := range tests {
+		if got := samePlaceholders(source, tt.translated); got != tt.same {
+			t.Errorf("samePlaceholders(%q) = %v, want %v", tt.translated, got, tt.same)
+		}
+	}
+
+	if got := stripPlaceholders("读<x1>这个</x1>。<x2/>"); got != "读这个。" {
+		t.Errorf("Unexpected stripped text %q", got)
+	}
+
+	long := "The quick brown fox <x1>jumps</x1> over the lazy dog."
+	if got := qualityIssues(long, "敏捷的棕色狐狸跳过了懒狗。", "Chinese"); !reflect.DeepEqual(got, []QualityIssue{IssuePlaceholders}) {
+		t.Errorf("Expected only a placeholder issue, got %v", got)
+	}
+	if got := qualityIssues(long, "敏捷的棕色狐狸<x1>跳过</x1>了懒狗。", "Chinese"); len(got) != 0 {
+		t.Errorf("Expected no issues, got %v", got)
+	}
+}
+
+func TestInsertTranslation_RestoresInlineMarkup(t *testing.T) {
+	body := `<p>Read <em class="i">this</em> and <a id="r1" href="#fn1"><sup>1</sup></a>.</p>`
+	source := "Read <x1>this</x1> and <x2><x3>1</x3></x2>."
+
+	// 译文可以调整占位符的顺序
+	got := insertBody(body, map[string]string{source: "<x2><x3>1</x3></x2>读<x1>这个</x1>。"}, textSegment.replaceText)
+	if want := `<p><a id="r1" href="#fn1"><sup>1</sup></a>读<em class="i">这个</em>。</p>`; got != want {
+		t.Errorf("Unexpected monolingual output\n got: %s\nwant: %s", got, want)
+	}
+
+	// 双语模式下原文不变，译文中的元素去掉 id
+	got = insertBody(body, map[string]string{source: "读<x1>这个</x1>和<x2><x3>1</x3></x2>。"}, textSegment.insertBilingual)
+	want := body[:len(body)-len("</p>")] + `<div class="translation" style="color: #666; font-style: italic; margin-top: 0.5em;">读<em class="i">这个</em>和<a href="#fn1"><sup>1</sup></a>。</div></p>`
+	if got != want {
+		t.Errorf("Unexpected bilingual output\n got: %s\nwant: %s", got, want)
+	}
+
+	// 占位符缺失或嵌套错误时按纯文本插入
+	for _, translation := range []string{"读这个和1。", "读<x1>这个<x2></x1><x3>1</x3></x2>。"} {
+		got = insertBody(body, map[string]string{source: translation}, textSegment.replaceText)
+		if !strings.HasPrefix(got, "<p>读这个") || strings.Contains(got, "x1") || strings.Contains(got, "Read") {
+			t.Errorf("Expected plain text fallback for %q, got %s", translation, got)
+		}
+		got = insertBody(body, map[string]string{source: translation}, textSegment.insertBilingual)
+		if !strings.Contains(got, `margin-top: 0.5em;">读这个`) || strings.Contains(got, "x1") {
+			t.Errorf("Expected plain text fallback for %q, got %s", translation, got)
+		}
+	}
+}
+
+// placeholderClient 伪翻译占位符之间的文本，lose 时丢掉全部占位符；calls、prompts 只记录包含占位符的请求
+type placeholderClient struct {
+	lose    bool
+	calls   int
+	prompts []string
+}
+
+func (c *placeholderClient) Translate(text, targetLanguage, userPrompt string) (string, error) {
+	if hasPlaceholders(text) {
+		c.calls++
+		c.prompts = append(c.prompts, userPrompt)
+	}
+	var b strings.Builder
+	pos := 0
+	for _, tag := range placeholderTags(text) {
+		b.WriteString(pseudoTranslate(text[pos:tag.start]))
+		if !c.lose {
+			b.WriteString(text[tag.start:tag.end])
+		}
+		pos = tag.end
+	}
+	b.WriteString(pseudoTranslate(text[pos:]))
+	return b.String(), nil
+}
+
+func TestTranslateEPUB_InlineMarkup(t *testing.T) {
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  testOPF,
+		"OEBPS/text/a.xhtml": testChapter(`Read <em>this</em> now.<a id="r1" href="#fn1"><sup>1</sup></a>`),
+	})
+
+	for _, lose := range []bool{false, true} {
+		client := &placeholderClient{lose: lose}
+		dt := &DocumentTranslator{Client: client, Cache: newTestCache(t)}
+		output := filepath.Join(t.TempDir(), "out.epub")
+		if _, err := dt.TranslateEPUB("markup", path, output, "Chinese", "", "monolingual", nil, nil); err != nil {
+			t.Fatalf("TranslateEPUB failed: %v", err)
+		}
+		out, err := OpenEPUB(output)
+		if err != nil {
+			t.Fatalf("OpenEPUB failed: %v", err)
+		}
+		chapter := string(out.Files["OEBPS/text/a.xhtml"])
+
+		if !strings.Contains(client.prompts[0], "<x1>...</x1>") {
+			t.Errorf("Expected the placeholder instruction in the prompt, got %q", client.prompts[0])
+		}
+		if !lose {
+			want := "<p>" + pseudoTranslate("Read ") + "<em>" + pseudoTranslate("this") + "</em>" + pseudoTranslate(" now.") + `<a id="r1" href="#fn1"><sup>1</sup></a></p>`
+			if !strings.Contains(chapter, want) || client.calls != 1 {
+				t.Errorf("Expected restored inline markup after %d calls, got %s", client.calls, chapter)
+			}
+			continue
+		}
+
+		// 丢失占位符时重试一次，仍然丢失则标记并按纯文本插入
+		if client.calls != 1+maxQualityRetries || !strings.Contains(client.prompts[1], placeholderRetryInstruction) {
+			t.Errorf("Expected one retry asking for the placeholders, got %d calls: %q", client.calls, client.prompts)
+		}
+		flags := dt.QualityFlags()
+		if len(flags) != 1 || !slices.Contains(flags[0].Issues, IssuePlaceholders) {
+			t.Errorf("Expected a placeholder quality flag, got %+v", flags)
+		}
+		if !strings.Contains(chapter, "<p>"+pseudoTranslate("Read this now.1")) || strings.Contains(chapter, "x1") {
+			t.Errorf("Expected plain text fallback, got %s", chapter)
+		}
+	}
+}
@@ -6,19 +6,11 @@ import (
 )
 
 // TranslateMetadata 翻译 EPUB 元数据
-func TranslateMetadata(epub *EPUBFile, client interface{}, targetLanguage, userPrompt string, cache *Cache) error {
-	// 查找 OPF 文件
-	var opfPath string
-	var opfContent []byte
-	for name, content := range epub.Files {
-		if strings.HasSuffix(name, ".opf") {
-			opfPath = name
-			opfContent = content
-			break
-		}
-	}
-
-	if opfPath == "" {
+func TranslateMetadata(epub *EPUBFile, client interface{}, targetLanguage, userPrompt, provider string, cache *Cache) error {
+	// OPF 文件在打开时按 container.xml 确定
+	opfPath := epub.OPFPath
+	opfContent, ok := epub.Files[opfPath]
+	if !ok {
 		return nil // 没有找到 OPF 文件
 	}
 
@@ -71,7 +63,7 @@ func TranslateMetadata(epub *EPUBFile, client interface{}, targetLanguage, userP
 	}
 
 	// 批量翻译
-	translated, err := translateTitlesWithCache(fieldsToTranslate, client, targetLanguage, userPrompt, cache)
+	translated, err := translateTitlesWithCache(fieldsToTranslate, client, targetLanguage, userPrompt, provider, cache)
 	if err != nil {
 		return err
 	}
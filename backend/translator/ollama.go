@@ -0,0 +1,186 @@
+package translator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Ollama /api/chat Request/Response structures
+type OllamaChatRequest struct {
+	Model     string                 `json:"model"`
+	Messages  []Message              `json:"messages"`
+	Stream    bool                   `json:"stream"`
+	Options   map[string]interface{} `json:"options,omitempty"`
+	KeepAlive interface{}            `json:"keep_alive,omitempty"`
+}
+
+// OllamaChatChunk 流式响应中的一行（NDJSON）
+type OllamaChatChunk struct {
+	Model      string  `json:"model"`
+	Message    Message `json:"message"`
+	Done       bool    `json:"done"`
+	DoneReason string  `json:"done_reason"`
+	Error      string  `json:"error"`
+}
+
+// OllamaClient Ollama 本地模型翻译客户端
+type OllamaClient struct {
+	BaseURL   string
+	Model     string
+	Options   map[string]interface{}
+	KeepAlive interface{}
+	Client    *http.Client
+	// Timeout 流式读取时两次数据块之间允许的最长间隔，而不是整个请求的超时，
+	// 这样长章节只要模型在持续输出就不会被中断
+	Timeout time.Duration
+}
+
+// NewOllamaClient 创建新的 Ollama 客户端
+// 支持的 Extra 参数: num_ctx, num_predict, keep_alive, top_p, seed
+func NewOllamaClient(config ProviderConfig) *OllamaClient {
+	options := make(map[string]interface{})
+	if config.Temperature != nil {
+		options["temperature"] = *config.Temperature
+	}
+	if topP, ok := config.ExtraFloat("top_p"); ok {
+		options["top_p"] = topP
+	}
+	if seed, ok := config.ExtraInt("seed"); ok {
+		options["seed"] = seed
+	}
+	if numCtx, ok := config.ExtraInt("num_ctx"); ok && numCtx > 0 {
+		options["num_ctx"] = numCtx
+	}
+	if numPredict, ok := config.ExtraInt("num_predict"); ok {
+		options["num_predict"] = numPredict
+	} else if config.MaxTokens > 0 {
+		options["num_predict"] = config.MaxTokens
+	}
+
+	// keep_alive 可以是 "10m" 这样的时长字符串，也可以是秒数（-1 表示常驻内存）
+	var keepAlive interface{}
+	if v, ok := config.Extra["keep_alive"]; ok {
+		keepAlive = v
+	}
+
+	return &OllamaClient{
+		BaseURL:   config.APIURL,
+		Model:     config.Model,
+		Options:   options,
+		KeepAlive: keepAlive,
+		Client:    &http.Client{},
+		Timeout:   config.HTTPTimeout(60 * time.Second),
+	}
+}
+
+// endpoint 构造 /api/chat 请求地址
+func (c *OllamaClient) endpoint() string {
+	// 兼容 http://localhost:11434、.../api、.../api/generate 等写法
+	apiURL := strings.TrimSuffix(c.BaseURL, "/")
+	for _, suffix := range []string{"/api/chat", "/api/generate", "/api"} {
+		if strings.HasSuffix(apiURL, suffix) {
+			apiURL = strings.TrimSuffix(apiURL, suffix)
+			break
+		}
+	}
+	return apiURL + "/api/chat"
+}
+
+// Translate 使用 Ollama /api/chat 流式接口翻译文本
+func (c *OllamaClient) Translate(text, targetLanguage, userPrompt string) (string, error) {
+	reqBody := OllamaChatRequest{
+		Model: c.Model,
+		Messages: []Message{
+			{Role: "system", Content: buildSystemPrompt(targetLanguage, userPrompt)},
+			{Role: "user", Content: text},
+		},
+		Stream:    true,
+		Options:   c.Options,
+		KeepAlive: c.KeepAlive,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("marshal request failed: %w", err)
+	}
+
+	// 每收到一个数据块就重置计时器，超过 Timeout 没有新数据才取消请求
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stallTimer := time.AfterFunc(c.Timeout, cancel)
+	defer stallTimer.Stop()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint(), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("create request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// 发送请求
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return "", fmt.Errorf("send request failed: no response within %s", c.Timeout)
+		}
+		return "", fmt.Errorf("send request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newAPIError(resp)
+	}
+
+	// 逐行解析 NDJSON 流
+	var result strings.Builder
+	done := false
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		stallTimer.Reset(c.Timeout)
+
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var chunk OllamaChatChunk
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return "", fmt.Errorf("decode response failed: %w", err)
+		}
+		if chunk.Error != "" {
+			return "", fmt.Errorf("API error: %s", chunk.Error)
+		}
+
+		result.WriteString(chunk.Message.Content)
+		if chunk.Done {
+			// done_reason 为 length 表示达到 num_predict 被截断
+			if chunk.DoneReason == "length" {
+				return "", fmt.Errorf("%w: done_reason=%s", ErrIncompleteOutput, chunk.DoneReason)
+			}
+			done = true
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return "", fmt.Errorf("stream stalled: no data within %s", c.Timeout)
+		}
+		return "", fmt.Errorf("read stream failed: %w", err)
+	}
+	if !done {
+		return "", fmt.Errorf("stream ended before completion")
+	}
+
+	if result.Len() == 0 {
+		return "", fmt.Errorf("no translation returned")
+	}
+
+	return strings.TrimSpace(result.String()), nil
+}
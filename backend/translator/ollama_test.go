@@ -0,0 +1,88 @@
+package translator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOllamaClient_Translate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("Expected path '/api/chat', got %s", r.URL.Path)
+		}
+
+		var req OllamaChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+
+		if !req.Stream {
+			t.Error("Expected stream to be true")
+		}
+		if req.Model != "llama3" {
+			t.Errorf("Expected model 'llama3', got %s", req.Model)
+		}
+		if req.KeepAlive != "30m" {
+			t.Errorf("Expected keep_alive '30m', got %v", req.KeepAlive)
+		}
+		if req.Options["num_ctx"] != float64(8192) {
+			t.Errorf("Expected options.num_ctx 8192, got %v", req.Options["num_ctx"])
+		}
+		if req.Options["num_predict"] != float64(2048) {
+			t.Errorf("Expected options.num_predict 2048, got %v", req.Options["num_predict"])
+		}
+		if req.Options["temperature"] != 0.2 {
+			t.Errorf("Expected options.temperature 0.2, got %v", req.Options["temperature"])
+		}
+
+		// 分多行返回 NDJSON
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		flusher := w.(http.Flusher)
+		for _, line := range []string{
+			`{"model":"llama3","message":{"role":"assistant","content":"你"},"done":false}`,
+			`{"model":"llama3","message":{"role":"assistant","content":"好"},"done":false}`,
+			`{"model":"llama3","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop"}`,
+		} {
+			w.Write([]byte(line + "\n"))
+			flusher.Flush()
+		}
+	}))
+	defer server.Close()
+
+	temperature := 0.2
+	client := NewOllamaClient(ProviderConfig{
+		Type:        ProviderOllama,
+		APIURL:      server.URL + "/api/generate",
+		Model:       "llama3",
+		Temperature: &temperature,
+		MaxTokens:   2048,
+		Extra: map[string]interface{}{
+			"num_ctx":    float64(8192),
+			"keep_alive": "30m",
+		},
+	})
+
+	result, err := client.Translate("Hello", "Chinese", "")
+	if err != nil {
+		t.Fatalf("Translate failed: %v", err)
+	}
+	if result != "你好" {
+		t.Errorf("Expected translation '你好', got '%s'", result)
+	}
+}
+
+func TestOllamaClient_Translate_StreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error":"model 'missing' not found"}` + "\n"))
+	}))
+	defer server.Close()
+
+	client := NewOllamaClient(ProviderConfig{APIURL: server.URL, Model: "missing"})
+
+	_, err := client.Translate("Hello", "Chinese", "")
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+}
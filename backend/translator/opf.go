@@ -0,0 +1,113 @@
+package translator
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// containerPath OCF 容器文件，其中的 rootfile 指向 OPF
+const containerPath = "META-INF/container.xml"
+
+// opfMediaType rootfile 中 OPF 的媒体类型
+const opfMediaType = "application/oebps-package+xml"
+
+// SpineItem spine 中的一个内容文件
+type SpineItem struct {
+	// ID manifest 中的 id，Path 文件在压缩包中的路径
+	ID        string `json:"id"`
+	Path      string `json:"path"`
+	MediaType string `json:"mediaType"`
+	// Linear 是否属于主阅读顺序，linear="no" 的项（注释、答案等辅助内容）为 false
+	Linear bool `json:"linear"`
+}
+
+// findOPF 返回 OPF 文件路径：使用 container.xml 中第一个 OPF 类型的 rootfile，
+// 没有 container.xml 或其中的路径不存在时，取文件名排序后的第一个 .opf 文件
+func findOPF(files map[string][]byte) string {
+	var container struct {
+		Rootfiles []struct {
+			FullPath  string `xml:"full-path,attr"`
+			MediaType string `xml:"media-type,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if content, ok := files[containerPath]; ok && xml.Unmarshal(content, &container) == nil {
+		for _, rootfile := range container.Rootfiles {
+			if rootfile.MediaType != "" && rootfile.MediaType != opfMediaType {
+				continue
+			}
+			if _, ok := files[rootfile.FullPath]; ok {
+				return rootfile.FullPath
+			}
+		}
+	}
+
+	var candidates []string
+	for name := range files {
+		if strings.HasSuffix(strings.ToLower(name), ".opf") {
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	sort.Strings(candidates)
+	return candidates[0]
+}
+
+// parseSpine 解析 OPF 的 manifest 和 spine，按阅读顺序返回内容文件
+// manifest 中的 href 相对于 OPF 所在目录；spine 中重复引用的文件只保留第一次
+func parseSpine(opfPath string, content []byte) ([]SpineItem, error) {
+	var pkg struct {
+		Manifest []struct {
+			ID        string `xml:"id,attr"`
+			Href      string `xml:"href,attr"`
+			MediaType string `xml:"media-type,attr"`
+		} `xml:"manifest>item"`
+		Spine []struct {
+			IDRef  string `xml:"idref,attr"`
+			Linear string `xml:"linear,attr"`
+		} `xml:"spine>itemref"`
+	}
+	if err := xml.Unmarshal(content, &pkg); err != nil {
+		return nil, fmt.Errorf("解析 OPF 失败: %w", err)
+	}
+
+	manifest := make(map[string]SpineItem, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		href := item.Href
+		if unescaped, err := url.PathUnescape(href); err == nil {
+			href = unescaped
+		}
+		manifest[item.ID] = SpineItem{ID: item.ID, Path: path.Join(path.Dir(opfPath), href), MediaType: item.MediaType}
+	}
+
+	var spine []SpineItem
+	seen := make(map[string]bool)
+	for _, itemref := range pkg.Spine {
+		item, ok := manifest[itemref.IDRef]
+		if !ok || seen[item.Path] {
+			continue
+		}
+		seen[item.Path] = true
+		item.Linear = strings.TrimSpace(itemref.Linear) != "no"
+		spine = append(spine, item)
+	}
+	return spine, nil
+}
+
+// isHTMLFile 判断文件是否为 HTML/XHTML 内容文件，mediaType 为空时按扩展名判断
+func isHTMLFile(name, mediaType string) bool {
+	switch mediaType {
+	case "application/xhtml+xml", "text/html":
+		return true
+	case "":
+		ext := strings.ToLower(filepath.Ext(name))
+		return ext == ".html" || ext == ".xhtml" || ext == ".htm"
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package translator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testContainer 指向 OEBPS/book/package.opf 的 container.xml
+const testContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/book/package.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>`
+
+// testSpineOPF 包含非线性项、重复引用、非 HTML 项和 URL 编码路径的 OPF
+const testSpineOPF = `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Spine Book</dc:title>
+    <dc:language>en</dc:language>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="c1" href="../text/chapter%201.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c2" href="../text/chapter2.xhtml" media-type="application/xhtml+xml"/>
+    <item id="notes" href="../text/notes.xhtml" media-type="application/xhtml+xml"/>
+    <item id="map" href="../images/map.svg" media-type="image/svg+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="c2"/>
+    <itemref idref="notes" linear="no"/>
+    <itemref idref="map"/>
+    <itemref idref="c1"/>
+    <itemref idref="c2"/>
+    <itemref idref="missing"/>
+  </spine>
+</package>`
+
+func TestOpenEPUB_Spine(t *testing.T) {
+	path := writeTestEPUB(t, map[string]string{
+		containerPath:                testContainer,
+		"OEBPS/book/package.opf":     testSpineOPF,
+		"OEBPS/content.opf":          testOPF,
+		"OEBPS/book/nav.xhtml":       testChapter("Table of contents."),
+		"OEBPS/text/chapter 1.xhtml": testChapter("First chapter."),
+		"OEBPS/text/chapter2.xhtml":  testChapter("Second chapter."),
+		"OEBPS/text/notes.xhtml":     testChapter("A note."),
+		"OEBPS/text/extra.xhtml":     testChapter("Not in spine."),
+		"OEBPS/images/map.svg":       `<svg xmlns="http://www.w3.org/2000/svg"/>`,
+	})
+	epub, err := OpenEPUB(path)
+	if err != nil {
+		t.Fatalf("OpenEPUB failed: %v", err)
+	}
+
+	// 使用 container.xml 指向的 OPF，而不是其他 .opf 文件
+	if epub.OPFPath != "OEBPS/book/package.opf" || epub.Metadata.Title != "Spine Book" {
+		t.Errorf("Expected the rootfile OPF, got %q (%q)", epub.OPFPath, epub.Metadata.Title)
+	}
+
+	want := []SpineItem{
+		{ID: "c2", Path: "OEBPS/text/chapter2.xhtml", MediaType: "application/xhtml+xml", Linear: true},
+		{ID: "notes", Path: "OEBPS/text/notes.xhtml", MediaType: "application/xhtml+xml", Linear: false},
+		{ID: "map", Path: "OEBPS/images/map.svg", MediaType: "image/svg+xml", Linear: true},
+		{ID: "c1", Path: "OEBPS/text/chapter 1.xhtml", MediaType: "application/xhtml+xml", Linear: true},
+	}
+	if !reflect.DeepEqual(epub.Spine, want) {
+		t.Errorf("Unexpected spine:\n got: %+v\nwant: %+v", epub.Spine, want)
+	}
+
+	// 提取和插入都按 spine 顺序，只处理 spine 中的 HTML 文件
+	if got, want := epub.GetHTMLFiles(), []string{"OEBPS/text/chapter2.xhtml", "OEBPS/text/notes.xhtml", "OEBPS/text/chapter 1.xhtml"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	segments := epub.GetSegments()
+	if got := segmentTexts(segments); !reflect.DeepEqual(got, []string{"Second chapter.", "A note.", "First chapter."}) {
+		t.Errorf("Expected text in spine order, got %q", got)
+	}
+
+	translations := make(map[SegmentID]string)
+	for _, seg := range segments {
+		translations[seg.ID] = "译文"
+	}
+	translations["OEBPS/text/extra.xhtml#0:0"] = "译文"
+	if err := epub.InsertMonolingualTranslation(translations); err != nil {
+		t.Fatalf("InsertMonolingualTranslation failed: %v", err)
+	}
+	for name, content := range epub.Files {
+		translated := strings.Contains(string(content), "译文")
+		if inSpine := name != "OEBPS/text/extra.xhtml" && strings.HasPrefix(name, "OEBPS/text/"); translated != inSpine {
+			t.Errorf("%s: expected translated=%v", name, inSpine)
+		}
+	}
+}
+
+func TestOpenEPUB_NoSpine(t *testing.T) {
+	// 没有 container.xml 时使用 .opf 文件；OPF 无法解析时按文件名顺序处理所有 HTML 文件
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  `<package><manifest>`,
+		"OEBPS/text/b.xhtml": testChapter("Chapter B text."),
+		"OEBPS/text/a.html":  testChapter("Chapter A text."),
+	})
+	epub, err := OpenEPUB(path)
+	if err != nil {
+		t.Fatalf("OpenEPUB failed: %v", err)
+	}
+	if epub.OPFPath != "OEBPS/content.opf" || len(epub.Spine) != 0 {
+		t.Errorf("Unexpected OPF %q and spine %+v", epub.OPFPath, epub.Spine)
+	}
+	if got, want := epub.GetHTMLFiles(), []string{"OEBPS/text/a.html", "OEBPS/text/b.xhtml"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
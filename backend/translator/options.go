@@ -0,0 +1,49 @@
+package translator
+
+// TranslateOptions 文档翻译的可选行为
+type TranslateOptions struct {
+	// BatchSize 批量模式下每次请求最多包含的文本块数，<= 1 表示逐条翻译
+	BatchSize int
+	// BatchTokens 批量模式下每次请求的原文 token 预算，<= 0 表示只按条数限制
+	BatchTokens int
+	// Concurrency 同时进行的翻译请求数，<= 1 表示顺序翻译
+	Concurrency int
+	// ContextParagraphs 作为只读上下文附带的前文段落数，0 表示不附带
+	ContextParagraphs int
+	// ContextTranslations 上下文中同时附带前文的译文
+	// 前文需要先翻译完，因此启用后按顺序翻译
+	ContextTranslations bool
+	// Glossary 术语表，为空表示不使用
+	Glossary *Glossary
+	// ExtractGlossary 正式翻译前先提取术语并生成建议术语表，通过 Hooks.OnGlossaryProposal 交给调用方审核
+	ExtractGlossary bool
+	// MaxBlockTokens 单个文本块的 token 上限，超过时在句子边界拆分后分别翻译，<= 0 表示使用 DefaultMaxBlockTokens
+	MaxBlockTokens int
+	// FuzzyThreshold 翻译记忆库模糊匹配的相似度阈值（0~1），达到阈值的译文作为参考交给模型改写，<= 0 表示不使用
+	FuzzyThreshold float64
+	// Template 提示词模板，为空表示直接拼接用户提示词、术语和上下文
+	Template *PromptTemplate
+}
+
+// DefaultBatchTokens 批量模式下默认的原文 token 预算
+const DefaultBatchTokens = 2000
+
+// batchLimits 返回生效的批量参数
+func (o TranslateOptions) batchLimits() (maxSegments, maxTokens int) {
+	if o.BatchSize <= 1 {
+		return 1, 0
+	}
+	maxTokens = o.BatchTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultBatchTokens
+	}
+	return o.BatchSize, maxTokens
+}
+
+// concurrency 返回生效的并发数
+func (o TranslateOptions) concurrency() int {
+	if o.Concurrency <= 1 || (o.ContextParagraphs > 0 && o.ContextTranslations) {
+		return 1
+	}
+	return o.Concurrency
+}
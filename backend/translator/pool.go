@@ -0,0 +1,206 @@
+package translator
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+// progressCheckpointInterval 每完成多少组翻译保存一次进度
+const progressCheckpointInterval = 20
+
+// translateJob 一次文档翻译的参数和结果
+type translateJob struct {
+	taskID         string
+	sourceLanguage string
+	targetLanguage string
+	userPrompt     string
+	// blocks 按文档顺序排列的全部翻译单元（过长的文本块已拆分），用于构造上下文
+	blocks []string
+	// total 文本块总数，done 已完成数（包括进度恢复和缓存命中的）
+	total int
+	done  int
+	// translations 翻译单元在 blocks 中的下标 -> 译文，providers 原文 -> 产生译文的提供商
+	translations map[int]string
+	providers    map[string]string
+	// duplicates 待翻译单元的下标 -> 原文和提示词都与之相同的其他单元，译文直接共用
+	duplicates map[int][]int
+	// progressKeys 各翻译单元的进度键（文本块 ID），保存进度时按文本块记录
+	progressKeys []string
+	// flags 未通过质量检查的文本块
+	flags []QualityFlag
+	// drafts 审校过的文本块的初译和审校译文，未启用审校时为空
+	drafts map[string]ReviewDraft
+}
+
+// translatePending 使用有界的工作池翻译各组文本块，结果写入 job
+// pending 为待翻译文本块在 job.blocks 中的下标，groups 为 pending 的分组
+// 每次派发前检查任务状态；暂停时等待进行中的请求结束并保存进度后返回 ErrPaused
+// 启用审校时初译和审校各算一步进度
+func (dt *DocumentTranslator) translatePending(job *translateJob, pending []int, groups [][]int, progressCallback func(float64), checkStatus func() string) error {
+	passes := 1
+	if dt.Reviewer != nil {
+		passes = 2
+		if job.drafts == nil {
+			job.drafts = make(map[string]ReviewDraft)
+		}
+	}
+
+	var (
+		mu       sync.Mutex // 保护 job 中的映射、done、finished
+		saveMu   sync.Mutex // 串行化进度文件写入，保证后写入的快照更新
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, dt.Options.concurrency())
+		done     = job.done * passes
+		finished int
+	)
+
+	// advance 记录完成的步数并回调进度，调用方需持有 mu；在锁内回调，保证进度单调递增
+	advance := func(steps int) {
+		done += steps
+		if progressCallback != nil {
+			progressCallback(float64(done) / float64(job.total*passes))
+		}
+	}
+
+	saveProgress := func() {
+		saveMu.Lock()
+		defer saveMu.Unlock()
+
+		mu.Lock()
+		snapshot := progressSnapshot(job.translations, job.progressKeys)
+		providers := copyMap(job.providers)
+		var drafts map[string]ReviewDraft
+		if len(job.drafts) > 0 {
+			drafts = make(map[string]ReviewDraft, len(job.drafts))
+			for k, v := range job.drafts {
+				drafts[k] = v
+			}
+		}
+		mu.Unlock()
+
+		if err := dt.Cache.SaveProgressMap(job.taskID, snapshot); err != nil {
+			log.Printf("保存进度失败: %v", err)
+		}
+		if err := dt.Cache.SaveProviderMap(job.taskID, providers); err != nil {
+			log.Printf("保存提供商记录失败: %v", err)
+		}
+		if drafts != nil {
+			if err := dt.Cache.SaveDraftMap(job.taskID, drafts); err != nil {
+				log.Printf("保存审校记录失败: %v", err)
+			}
+		}
+	}
+
+	// positions 为本组文本块在 job.blocks 中的下标；prompts 为每个文本块单独翻译时的提示词，用作缓存键；
+	// groupPrompt 为本组请求实际使用的提示词
+	worker := func(positions []int, texts, prompts []string, groupPrompt string) {
+		defer wg.Done()
+		defer func() { <-sem }()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("翻译工作协程异常: %v", fmt.Sprint(r))
+			}
+		}()
+
+		// 翻译失败的文本块不会出现在结果中，也不写入缓存，下次重试时会重新翻译
+		results := dt.translateGroup(texts, job.targetLanguage, groupPrompt)
+
+		// 审校初译；审校失败的文本块保留初译，但不以审校缓存键写入缓存，下次重新审校
+		var drafts map[string]ReviewDraft
+		if dt.Reviewer != nil {
+			mu.Lock()
+			advance(len(texts))
+			mu.Unlock()
+			drafts = dt.reviewResults(texts, prompts, job.targetLanguage, results)
+		}
+
+		for i, text := range texts {
+			if result, ok := results[text]; ok {
+				if _, reviewed := drafts[text]; dt.Reviewer != nil && !reviewed {
+					continue
+				}
+				dt.Cache.SetEntry(dt.cacheKey(text, job.targetLanguage, prompts[i]), result.Text, result.Provider)
+				// 未通过质量检查的译文不进入翻译记忆库
+				if len(result.Issues) == 0 {
+					dt.remember(text, result, job.sourceLanguage, job.targetLanguage)
+				}
+			}
+		}
+
+		mu.Lock()
+		for i, pos := range positions {
+			result, ok := results[texts[i]]
+			if !ok {
+				continue
+			}
+			job.translations[pos] = result.Text
+			for _, dup := range job.duplicates[pos] {
+				job.translations[dup] = result.Text
+			}
+		}
+		for original, result := range results {
+			job.providers[original] = result.Provider
+			if len(result.Issues) > 0 {
+				job.flags = append(job.flags, QualityFlag{Source: original, Translation: result.Text, Issues: result.Issues})
+			}
+		}
+		for original, draft := range drafts {
+			job.drafts[original] = draft
+		}
+		advance(len(texts))
+		finished++
+		checkpoint := finished%progressCheckpointInterval == 0
+		mu.Unlock()
+
+		if checkpoint {
+			saveProgress()
+		}
+	}
+
+	paused := false
+	for _, group := range groups {
+		// 先占用一个并发名额，再检查任务状态，使检查尽量贴近实际派发
+		sem <- struct{}{}
+		if checkStatus != nil && checkStatus() == "paused" {
+			<-sem
+			paused = true
+			break
+		}
+
+		texts := make([]string, len(group))
+		prompts := make([]string, len(group))
+		positions := make([]int, len(group))
+		mu.Lock()
+		for i, idx := range group {
+			pos := pending[idx]
+			positions[i] = pos
+			texts[i] = job.blocks[pos]
+			prompts[i] = dt.segmentPrompt(job.blocks, []int{pos}, job.targetLanguage, job.userPrompt, job.translations)
+		}
+		groupPrompt := prompts[0]
+		if len(group) > 1 {
+			groupPrompt = dt.segmentPrompt(job.blocks, positions, job.targetLanguage, job.userPrompt, job.translations)
+		}
+		mu.Unlock()
+
+		wg.Add(1)
+		go worker(positions, texts, prompts, groupPrompt)
+	}
+
+	wg.Wait()
+
+	if paused {
+		saveProgress()
+		return ErrPaused
+	}
+	return nil
+}
+
+func copyMap(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
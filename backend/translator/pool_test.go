@@ -0,0 +1,115 @@
+package translator
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowClient 记录同时进行的请求数
+type slowClient struct {
+	active int32
+	peak   int32
+	calls  int32
+}
+
+func (c *slowClient) Translate(text, targetLanguage, userPrompt string) (string, error) {
+	atomic.AddInt32(&c.calls, 1)
+	n := atomic.AddInt32(&c.active, 1)
+	for {
+		peak := atomic.LoadInt32(&c.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&c.peak, peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	atomic.AddInt32(&c.active, -1)
+	return "translated:" + text, nil
+}
+
+func newTestCache(t *testing.T) *Cache {
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCache failed: %v", err)
+	}
+	return cache
+}
+
+func TestTranslatePending_BoundedConcurrency(t *testing.T) {
+	client := &slowClient{}
+	dt := &DocumentTranslator{Client: client, Cache: newTestCache(t), Options: TranslateOptions{Concurrency: 3}}
+
+	blocks := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+	pending := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	groups := packBatches(blocks, 1, 0)
+	translations := make(map[int]string)
+
+	var mu sync.Mutex
+	var progress []float64
+	callback := func(p float64) {
+		mu.Lock()
+		progress = append(progress, p)
+		mu.Unlock()
+	}
+
+	job := &translateJob{taskID: "pool", targetLanguage: "Chinese", blocks: blocks, total: len(blocks), translations: translations, providers: map[string]string{}}
+	if err := dt.translatePending(job, pending, groups, callback, nil); err != nil {
+		t.Fatalf("translatePending failed: %v", err)
+	}
+
+	if peak := atomic.LoadInt32(&client.peak); peak > 3 || peak < 2 {
+		t.Errorf("Expected peak concurrency between 2 and 3, got %d", peak)
+	}
+	for i, text := range blocks {
+		if translations[i] != "translated:"+text {
+			t.Errorf("Missing translation for %q", text)
+		}
+	}
+	if len(progress) != len(blocks) || progress[len(progress)-1] != 1 {
+		t.Fatalf("Unexpected progress: %v", progress)
+	}
+	for i := 1; i < len(progress); i++ {
+		if progress[i] < progress[i-1] {
+			t.Errorf("Progress is not monotonic: %v", progress)
+		}
+	}
+}
+
+func TestTranslatePending_Pause(t *testing.T) {
+	client := &slowClient{}
+	cache := newTestCache(t)
+	dt := &DocumentTranslator{Client: client, Cache: cache, Options: TranslateOptions{Concurrency: 2}}
+
+	blocks := []string{"a", "b", "c", "d", "e", "f"}
+	pending := []int{0, 1, 2, 3, 4, 5}
+	groups := packBatches(blocks, 1, 0)
+	translations := make(map[int]string)
+
+	var checks int32
+	checkStatus := func() string {
+		if atomic.AddInt32(&checks, 1) > 2 {
+			return "paused"
+		}
+		return "processing"
+	}
+
+	blocks[2] = "a"
+	keys := []string{"ch1#0:0", "ch1#1:0", "ch2#0:0", "ch2#1:0", "ch2#2:0", "ch2#3:0"}
+	job := &translateJob{taskID: "pause", targetLanguage: "Chinese", blocks: blocks, total: len(blocks), translations: translations, providers: map[string]string{}, progressKeys: keys}
+	if err := dt.translatePending(job, pending, groups, nil, checkStatus); err != ErrPaused {
+		t.Fatalf("Expected ErrPaused, got %v", err)
+	}
+	if client.calls != 2 {
+		t.Errorf("Expected 2 dispatched requests, got %d", client.calls)
+	}
+
+	saved, err := cache.LoadProgressMap("pause")
+	if err != nil {
+		t.Fatalf("LoadProgressMap failed: %v", err)
+	}
+	// 进度按文本块 ID 保存，未派发的文本块即使原文相同也没有进度
+	if len(saved) != 2 || saved["ch1#0:0"] != "translated:a" || saved["ch1#1:0"] != "translated:b" {
+		t.Errorf("Unexpected saved progress: %v", saved)
+	}
+}
@@ -0,0 +1,75 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultCurrency 价格表未指定币种时使用的币种
+const DefaultCurrency = "USD"
+
+// ModelPrice 模型每百万 token 的价格
+type ModelPrice struct {
+	Input    float64 `json:"input"`
+	Output   float64 `json:"output"`
+	Currency string  `json:"currency,omitempty"`
+}
+
+// Cost 按 token 数计算费用
+func (p ModelPrice) Cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*p.Input + float64(outputTokens)*p.Output) / 1e6
+}
+
+// PriceTable 模型价格表，键为 "提供商/模型"、"模型"，或以 * 结尾的前缀（如 "claude/*"、"gpt-4o*"）
+type PriceTable map[string]ModelPrice
+
+// LoadPriceTable 从 JSON 文件加载价格表，文件不存在时返回空表
+func LoadPriceTable(path string) (PriceTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return PriceTable{}, nil
+		}
+		return nil, fmt.Errorf("读取价格表失败: %w", err)
+	}
+	var table PriceTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("解析价格表失败: %w", err)
+	}
+	return table, nil
+}
+
+// Lookup 查找模型价格：先按 "提供商/模型" 和 "模型" 精确匹配，再取最长的前缀匹配
+func (t PriceTable) Lookup(provider, model string) (ModelPrice, bool) {
+	label := provider + "/" + model
+	for _, key := range []string{label, model} {
+		if price, ok := t[key]; ok {
+			return withCurrency(price), true
+		}
+	}
+
+	best := ""
+	for key := range t {
+		prefix, ok := strings.CutSuffix(key, "*")
+		if !ok || len(prefix) < len(best) {
+			continue
+		}
+		if strings.HasPrefix(label, prefix) || strings.HasPrefix(model, prefix) {
+			best = prefix
+		}
+	}
+	if price, ok := t[best+"*"]; ok {
+		return withCurrency(price), true
+	}
+	return ModelPrice{}, false
+}
+
+// withCurrency 补上默认币种
+func withCurrency(price ModelPrice) ModelPrice {
+	if price.Currency == "" {
+		price.Currency = DefaultCurrency
+	}
+	return price
+}
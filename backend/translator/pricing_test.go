@@ -0,0 +1,63 @@
+package translator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPriceTable_Lookup(t *testing.T) {
+	table := PriceTable{
+		"openai/gpt-4o":  {Input: 2.5, Output: 10},
+		"gpt-4o-mini":    {Input: 0.15, Output: 0.6},
+		"claude/*":       {Input: 3, Output: 15},
+		"claude/haiku-*": {Input: 0.8, Output: 4},
+		"deepseek-*":     {Input: 1, Output: 2, Currency: "CNY"},
+	}
+	tests := []struct {
+		provider, model string
+		want            float64
+		currency        string
+		ok              bool
+	}{
+		{"openai", "gpt-4o", 2.5, "USD", true},
+		{"azure", "gpt-4o-mini", 0.15, "USD", true},
+		{"claude", "sonnet-4", 3, "USD", true},
+		// 取最长的前缀
+		{"claude", "haiku-3.5", 0.8, "USD", true},
+		{"deepseek", "deepseek-chat", 1, "CNY", true},
+		{"openai", "o3", 0, "", false},
+	}
+	for _, tt := range tests {
+		price, ok := table.Lookup(tt.provider, tt.model)
+		if ok != tt.ok || price.Input != tt.want || price.Currency != tt.currency {
+			t.Errorf("Lookup(%s, %s) = %+v, %v", tt.provider, tt.model, price, ok)
+		}
+	}
+
+	if got := (ModelPrice{Input: 2, Output: 8}).Cost(500_000, 250_000); got != 3 {
+		t.Errorf("Expected cost 3, got %v", got)
+	}
+}
+
+func TestLoadPriceTable(t *testing.T) {
+	table, err := LoadPriceTable(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || len(table) != 0 {
+		t.Fatalf("Expected empty table for missing file, got %v, %v", table, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "prices.json")
+	os.WriteFile(path, []byte(`{"gpt-4o": {"input": 2.5, "output": 10}}`), 0644)
+	table, err = LoadPriceTable(path)
+	if err != nil {
+		t.Fatalf("LoadPriceTable failed: %v", err)
+	}
+	if price, ok := table.Lookup("openai", "gpt-4o"); !ok || price.Output != 10 {
+		t.Errorf("Unexpected price %+v", price)
+	}
+
+	os.WriteFile(path, []byte(`not json`), 0644)
+	if _, err := LoadPriceTable(path); err == nil {
+		t.Error("Expected invalid JSON to be rejected")
+	}
+}
@@ -0,0 +1,248 @@
+package translator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// PromptData 提示词模板可用的变量
+type PromptData struct {
+	TargetLanguage string
+	SourceLanguage string
+	Title          string // 书名（EPUB 元数据）
+	Author         string // 作者（EPUB 元数据）
+	Chapter        string // 当前章节标题
+	Glossary       string // 相关术语说明，没有时为空
+	Reference      string // 翻译记忆库的参考译文，没有时为空
+	Context        string // 前文上下文，没有时为空
+	Instructions   string // 任务中填写的用户提示词
+}
+
+// promptPartials 所有模板共用的子模板，自定义模板也可以通过 {{template "book" .}} 和 {{template "extras" .}} 引用
+const promptPartials = `{{define "book"}}{{if .Title}}You are translating the book "{{.Title}}"{{if .Author}} by {{.Author}}{{end}}{{if .SourceLanguage}} from {{.SourceLanguage}}{{end}} into {{.TargetLanguage}}.{{end}}
+{{if .Chapter}}Current chapter: {{.Chapter}}{{end}}{{end}}
+{{define "extras"}}{{.Instructions}}
+
+{{.Glossary}}
+
+{{.Reference}}
+
+{{.Context}}
+
+Do not add explanations, notes or quotes around the translation.{{end}}`
+
+// promptPresets 内置的提示词模板
+var promptPresets = map[string]string{
+	"literary": `{{template "book" .}}
+This is literary prose. Preserve the author's voice, tone, rhythm and imagery. Render dialogue so it sounds natural in {{.TargetLanguage}}, keep names and forms of address consistent, and prefer idiomatic phrasing over word-for-word fidelity without adding or omitting content.
+{{template "extras" .}}`,
+
+	"technical": `{{template "book" .}}
+This is technical writing. Be precise and consistent with terminology; prefer the established {{.TargetLanguage}} terms of the field. Keep code, commands, file names, identifiers, URLs, numbers and units exactly as in the source. Keep sentences clear and concise.
+{{template "extras" .}}`,
+
+	"dictionary": `{{template "book" .}}
+The text comes from a dictionary or glossary. Translate headwords, definitions and example sentences faithfully and concisely. Keep the original structure, numbering, abbreviations (such as n., v., adj.) and punctuation; do not merge or expand entries.
+{{template "extras" .}}`,
+
+	"children": `{{template "book" .}}
+This is a children's book. Use simple, warm and lively {{.TargetLanguage}} that young readers can follow, with short sentences and everyday words. Keep rhymes, wordplay and sound effects playful where possible, and keep the story exactly as told.
+{{template "extras" .}}`,
+}
+
+// PromptTemplate 解析后的提示词模板
+// 渲染结果是用户提示词部分，跟在客户端固定的“翻译为目标语言”说明之后
+type PromptTemplate struct {
+	Name string
+	tmpl *template.Template
+}
+
+// maxTemplateOutput 模板渲染结果中除变量内容外最多的字节数，防止 {{range}} 等写法生成过大的提示词
+const maxTemplateOutput = 64 << 10
+
+// errTemplateOutputTooLarge 模板渲染结果超过上限
+var errTemplateOutputTooLarge = errors.New("prompt template output too large")
+
+// limitedWriter 最多写入 n 字节，超出时返回 errTemplateOutputTooLarge，模板随即停止执行
+type limitedWriter struct {
+	w io.Writer
+	n int
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if len(p) > l.n {
+		return 0, errTemplateOutputTooLarge
+	}
+	l.n -= len(p)
+	return l.w.Write(p)
+}
+
+// outputLimit 渲染 data 时允许的最大字节数：变量内容加上 maxTemplateOutput
+func (data PromptData) outputLimit() int {
+	return maxTemplateOutput + len(data.TargetLanguage) + len(data.SourceLanguage) + len(data.Title) + len(data.Author) +
+		len(data.Chapter) + len(data.Glossary) + len(data.Reference) + len(data.Context) + len(data.Instructions)
+}
+
+// ParsePromptTemplate 解析并校验提示词模板
+// 使用示例数据试渲染一次，引用不存在的变量、输出过大等错误会在这里返回
+func ParsePromptTemplate(name, text string) (*PromptTemplate, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("提示词模板不能为空")
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(promptPartials)
+	if err == nil {
+		tmpl, err = tmpl.Parse(text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("提示词模板语法错误: %w", err)
+	}
+
+	sample := PromptData{
+		TargetLanguage: "Chinese", SourceLanguage: "English", Title: "Title", Author: "Author", Chapter: "Chapter 1",
+		Glossary: "Glossary", Reference: "Reference", Context: "Context", Instructions: "Instructions",
+	}
+	if err := tmpl.Execute(&limitedWriter{w: io.Discard, n: sample.outputLimit()}, sample); err != nil {
+		if errors.Is(err, errTemplateOutputTooLarge) {
+			return nil, fmt.Errorf("提示词模板渲染结果超过 %d KiB", maxTemplateOutput>>10)
+		}
+		return nil, fmt.Errorf("提示词模板无法渲染: %w", err)
+	}
+	return &PromptTemplate{Name: name, tmpl: tmpl}, nil
+}
+
+// blankLines 渲染后连续的空行（变量为空时留下）
+var blankLines = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+`)
+
+// Render 渲染模板，多余的空行会被压缩
+func (t *PromptTemplate) Render(data PromptData) (string, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&limitedWriter{w: &b, n: data.outputLimit()}, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(b.String(), "\n\n")), nil
+}
+
+// PromptPresetNames 返回内置模板名称
+func PromptPresetNames() []string {
+	names := make([]string, 0, len(promptPresets))
+	for name := range promptPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PromptPresetText 返回内置模板的原文
+func PromptPresetText(name string) (string, bool) {
+	text, ok := promptPresets[name]
+	return text, ok
+}
+
+// ResolvePromptTemplate 根据任务配置选择模板：自定义模板优先，其次是内置模板，都为空时返回 nil（不使用模板）
+func ResolvePromptTemplate(preset, custom string) (*PromptTemplate, error) {
+	if strings.TrimSpace(custom) != "" {
+		return ParsePromptTemplate("custom", custom)
+	}
+	if preset == "" {
+		return nil, nil
+	}
+	text, ok := promptPresets[preset]
+	if !ok {
+		return nil, fmt.Errorf("未知的提示词模板: %s", preset)
+	}
+	return ParsePromptTemplate(preset, text)
+}
+
+// bookInfo 当前翻译的书籍信息，用于渲染提示词模板
+type bookInfo struct {
+	Title          string
+	Author         string
+	SourceLanguage string
+	// chapters 与翻译单元一一对应的章节标题
+	chapters []string
+}
+
+// chapter 返回第 index 个翻译单元所在的章节标题
+func (b bookInfo) chapter(index int) string {
+	if index < 0 || index >= len(b.chapters) {
+		return ""
+	}
+	return b.chapters[index]
+}
+
+// promptParts 按文本块生成的提示词片段
+type promptParts struct {
+	// SourceLanguage 检测到的文本块语言代码，无法判断时为空
+	SourceLanguage string
+	Chapter        string
+	Glossary       string
+	Reference      string
+	Context        string
+}
+
+// composePrompt 组合用户提示词和各片段
+// 未设置模板时依次拼接；设置了模板时渲染模板，渲染失败则退回拼接
+func (dt *DocumentTranslator) composePrompt(targetLanguage, userPrompt string, parts promptParts) string {
+	if dt.Options.Template == nil {
+		return joinPrompt(sourceLanguageLine(parts.SourceLanguage), userPrompt, parts.Glossary, parts.Reference, parts.Context)
+	}
+
+	sourceLanguage := parts.SourceLanguage
+	if sourceLanguage == "" {
+		sourceLanguage = dt.book.SourceLanguage
+	}
+	rendered, err := dt.Options.Template.Render(PromptData{
+		TargetLanguage: targetLanguage,
+		SourceLanguage: languageName(sourceLanguage),
+		Title:          dt.book.Title,
+		Author:         dt.book.Author,
+		Chapter:        parts.Chapter,
+		Glossary:       parts.Glossary,
+		Reference:      parts.Reference,
+		Context:        parts.Context,
+		Instructions:   userPrompt,
+	})
+	if err != nil {
+		log.Printf("渲染提示词模板 %s 失败，使用默认提示词: %v", dt.Options.Template.Name, err)
+		return joinPrompt(sourceLanguageLine(parts.SourceLanguage), userPrompt, parts.Glossary, parts.Reference, parts.Context)
+	}
+	return rendered
+}
+
+// sourceLanguageLine 说明原文语言的提示词，语言未知时为空
+func sourceLanguageLine(lang string) string {
+	if lang == "" {
+		return ""
+	}
+	return "The source text is in " + languageName(lang) + "."
+}
+
+// segmentLanguage 返回一组文本块共同的检测语言，语言不一致或未检测时返回空
+func (dt *DocumentTranslator) segmentLanguage(texts []string) string {
+	lang := ""
+	for i, text := range texts {
+		detected := dt.blockLanguages[text]
+		if detected == "" || (i > 0 && detected != lang) {
+			return ""
+		}
+		lang = detected
+	}
+	return lang
+}
+
+// joinPrompt 用空行拼接非空的提示词片段
+func joinPrompt(parts ...string) string {
+	var nonEmpty []string
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, "\n\n")
+}
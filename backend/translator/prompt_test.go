@@ -0,0 +1,106 @@
+package translator
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePromptTemplate(t *testing.T) {
+	for _, name := range PromptPresetNames() {
+		if _, err := ResolvePromptTemplate(name, ""); err != nil {
+			t.Errorf("Preset %s failed to parse: %v", name, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"{{.Title",
+		"Translate {{.Book}} carefully.",
+		`{{template "missing" .}}`,
+		"{{range 1000000000}}x{{end}}",
+	}
+	for _, text := range invalid {
+		if _, err := ParsePromptTemplate("custom", text); err == nil {
+			t.Errorf("Expected %q to be rejected", text)
+		}
+	}
+
+	if _, err := ResolvePromptTemplate("poetry", ""); err == nil {
+		t.Error("Expected unknown preset to be rejected")
+	}
+	if tmpl, err := ResolvePromptTemplate("", ""); err != nil || tmpl != nil {
+		t.Errorf("Expected no template, got %v, %v", tmpl, err)
+	}
+}
+
+func TestPromptTemplate_Render(t *testing.T) {
+	tmpl, err := ResolvePromptTemplate("literary", "")
+	if err != nil {
+		t.Fatalf("ResolvePromptTemplate failed: %v", err)
+	}
+
+	got, err := tmpl.Render(PromptData{TargetLanguage: "Chinese", Title: "Dune", Author: "Frank Herbert", Instructions: "Keep it formal."})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	for _, want := range []string{`"Dune" by Frank Herbert into Chinese.`, "literary prose", "Keep it formal."} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in rendered prompt, got %q", want, got)
+		}
+	}
+	// 空变量不留下多余的空行
+	if strings.Contains(got, "\n\n\n") || strings.Contains(got, "Current chapter") {
+		t.Errorf("Expected empty sections to be dropped, got %q", got)
+	}
+}
+
+func TestBuildSystemPrompt_OutputOnly(t *testing.T) {
+	dt := &DocumentTranslator{}
+	prompt := dt.composePrompt("Chinese", "Keep it formal.", promptParts{SourceLanguage: "en"})
+	got := buildSystemPrompt("Chinese", prompt)
+	// 检测到源语言等附加片段时仍然要求只返回译文，附加片段跟在其后
+	only := strings.Index(got, outputOnlyInstruction)
+	source := strings.Index(got, "The source text is in English.")
+	if only < 0 || source < 0 || source < only || !strings.Contains(got, "Keep it formal.") {
+		t.Errorf("Expected output-only instruction followed by the prompt parts, got %q", got)
+	}
+}
+
+func TestTranslateEPUB_PromptTemplate(t *testing.T) {
+	chapter := `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title>t</title></head><body>
+<h1>The <em>Beginning</em></h1>
+<p>First paragraph.</p>
+</body></html>`
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  testOPF,
+		"OEBPS/text/a.xhtml": testChapter("Second paragraph."),
+		"OEBPS/text/b.xhtml": chapter,
+	})
+
+	tmpl, err := ParsePromptTemplate("custom", "{{.Title}}|{{.Author}}|{{.SourceLanguage}}->{{.TargetLanguage}}|{{.Chapter}}|{{.Instructions}}")
+	if err != nil {
+		t.Fatalf("ParsePromptTemplate failed: %v", err)
+	}
+	client := &recordingClient{}
+	dt := &DocumentTranslator{
+		Client:   client,
+		Cache:    newTestCache(t),
+		Provider: "test/model",
+		Options:  TranslateOptions{Template: tmpl},
+	}
+
+	output := filepath.Join(t.TempDir(), "out.epub")
+	if _, err := dt.TranslateEPUB("tmpl", path, output, "Chinese", "Be brief.", "bilingual", nil, nil); err != nil {
+		t.Fatalf("TranslateEPUB failed: %v", err)
+	}
+
+	if got, want := client.prompts["First paragraph."], "Test Book|Tester|English->Chinese|The Beginning|Be brief."; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	// 没有标题的章节使用 <title>
+	if got, want := client.prompts["Second paragraph."], "Test Book|Tester|English->Chinese|t|Be brief."; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,213 @@
+package translator
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// QualityIssue 译文质量问题
+type QualityIssue string
+
+const (
+	// IssueUnchanged 译文与原文相同（原文不是目标语言）
+	IssueUnchanged QualityIssue = "unchanged"
+	// IssueWrongScript 译文的文字不属于目标语言
+	IssueWrongScript QualityIssue = "wrong_script"
+	// IssueLengthRatio 译文与原文的长度比例超出合理范围，可能被截断或夹带了说明
+	IssueLengthRatio QualityIssue = "length_ratio"
+	// IssuePlaceholders 译文丢失、重复或改动了原文中代表内联元素的占位符
+	IssuePlaceholders QualityIssue = "placeholders"
+)
+
+const (
+	// minCheckLetters 原文字母数少于该值时不检查是否未翻译和文字，短文本（人名、数字、代码）原样保留很常见
+	minCheckLetters = 12
+	// minRatioTokens 原文 token 数少于该值时不检查长度比例
+	minRatioTokens = 20
+	// minLengthRatio、maxLengthRatio 译文与原文估算 token 数之比的合理范围
+	minLengthRatio = 0.25
+	maxLengthRatio = 4.0
+	// minScriptShare 译文字母中属于目标语言文字的最低比例
+	minScriptShare = 0.5
+	// maxQualityRetries 译文不合格时重新翻译的次数，仍不合格时保留译文并标记
+	maxQualityRetries = 1
+)
+
+// ErrQuality 译文未通过质量检查
+var ErrQuality = errors.New("translation failed quality check")
+
+// QualityError 译文未通过质量检查的具体问题
+type QualityError struct {
+	Issues []QualityIssue
+}
+
+func (e *QualityError) Error() string {
+	issues := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		issues[i] = string(issue)
+	}
+	return fmt.Sprintf("%v: %s", ErrQuality, strings.Join(issues, ", "))
+}
+
+// Unwrap 使 errors.Is(err, ErrQuality) 成立
+func (e *QualityError) Unwrap() error {
+	return ErrQuality
+}
+
+// QualityFlag 重试后仍未通过质量检查、保留在译文中的文本块
+type QualityFlag struct {
+	Source      string         `json:"source"`
+	Translation string         `json:"translation"`
+	Issues      []QualityIssue `json:"issues"`
+}
+
+// languageScripts 语言（主语言代码）对应的文字
+var languageScripts = map[string][]*unicode.RangeTable{
+	"zh": {unicode.Han},
+	"ja": {unicode.Han, unicode.Hiragana, unicode.Katakana},
+	"ko": {unicode.Hangul, unicode.Han},
+	"ru": {unicode.Cyrillic}, "uk": {unicode.Cyrillic}, "bg": {unicode.Cyrillic}, "sr": {unicode.Cyrillic},
+	"ar": {unicode.Arabic}, "fa": {unicode.Arabic},
+	"he": {unicode.Hebrew},
+	"el": {unicode.Greek},
+	"th": {unicode.Thai},
+	"hi": {unicode.Devanagari},
+	"en": {unicode.Latin}, "fr": {unicode.Latin}, "de": {unicode.Latin}, "es": {unicode.Latin},
+	"it": {unicode.Latin}, "pt": {unicode.Latin}, "nl": {unicode.Latin}, "pl": {unicode.Latin},
+	"vi": {unicode.Latin}, "tr": {unicode.Latin}, "id": {unicode.Latin}, "sv": {unicode.Latin},
+}
+
+// targetScripts 返回目标语言的文字，未知语言返回 nil（不检查文字）
+func targetScripts(targetLanguage string) []*unicode.RangeTable {
+	primary, _, _ := strings.Cut(NormalizeLanguage(targetLanguage), "-")
+	return languageScripts[primary]
+}
+
+// sharedScript 判断文字是否为多种语言共用
+func sharedScript(script *unicode.RangeTable) bool {
+	return script == unicode.Latin || script == unicode.Cyrillic || script == unicode.Arabic
+}
+
+// scriptShare 返回文本中的字母数，以及其中属于 scripts 的比例
+// 比例按 token 估算加权（CJK 字符计 4，其他字母计 1），这样中文译文中夹带的英文标识符不会被误判
+func scriptShare(text string, scripts []*unicode.RangeTable) (letters int, share float64) {
+	var matched, total int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		weight := 1
+		if isCJK(r) {
+			weight = 4
+		}
+		total += weight
+		if unicode.IsOneOf(scripts, r) {
+			matched += weight
+		}
+	}
+	if total == 0 {
+		return 0, 0
+	}
+	return letters, float64(matched) / float64(total)
+}
+
+// CheckTranslation 检查译文质量，未通过时返回 *QualityError
+// 检查项：原文不是目标语言时译文与原文相同、译文文字不属于目标语言、译文与原文长度比例异常、占位符不一致
+// 占位符不计入其余各项的检查
+func CheckTranslation(source, translated, targetLanguage string) error {
+	var issues []QualityIssue
+	placeholdersKept := samePlaceholders(source, translated)
+	source, translated = stripPlaceholders(source), stripPlaceholders(translated)
+
+	scripts := targetScripts(targetLanguage)
+	sourceLetters, sourceShare := scriptShare(source, scripts)
+	// 拉丁、西里尔、阿拉伯字母为多种语言共用，无法据此判断原文是否已是目标语言
+	sourceInTarget := scripts != nil && !sharedScript(scripts[0]) && sourceShare >= minScriptShare
+
+	if sourceLetters >= minCheckLetters && !sourceInTarget && tmKey(source) == tmKey(translated) {
+		issues = append(issues, IssueUnchanged)
+	} else if scripts != nil {
+		if letters, share := scriptShare(translated, scripts); letters >= minCheckLetters && share < minScriptShare {
+			issues = append(issues, IssueWrongScript)
+		}
+	}
+
+	if sourceTokens := EstimateTokens(source); sourceTokens >= minRatioTokens {
+		ratio := float64(EstimateTokens(translated)) / float64(sourceTokens)
+		if ratio < minLengthRatio || ratio > maxLengthRatio {
+			issues = append(issues, IssueLengthRatio)
+		}
+	}
+
+	if !placeholdersKept {
+		issues = append(issues, IssuePlaceholders)
+	}
+
+	if len(issues) == 0 {
+		return nil
+	}
+	return &QualityError{Issues: issues}
+}
+
+// qualityIssues 返回 CheckTranslation 发现的问题
+func qualityIssues(source, translated, targetLanguage string) []QualityIssue {
+	var qualityErr *QualityError
+	if errors.As(CheckTranslation(source, translated, targetLanguage), &qualityErr) {
+		return qualityErr.Issues
+	}
+	return nil
+}
+
+// translationPreamble 模型在译文前添加的说明，如 "Here is the translation:"、"以下是译文："
+var translationPreamble = regexp.MustCompile(`(?i)^\s*(?:(?:sure|certainly|of course|okay|ok)[,.!]?\s*)?` +
+	`(?:(?:here(?:'s| is) (?:the |your |my )?(?:\w+ )?translation(?: (?:of|into|in) [^:\n]{1,40})?|(?:the )?translat(?:ion|ed text)(?: \([^)\n]{1,30}\))?)\s*[:：]` +
+	`|(?:以下是|下面是)?[^\n：:]{0,12}(?:译文|翻译)(?:如下)?\s*[：:])\s*`)
+
+// wrappingQuotes 成对的引号
+var wrappingQuotes = [][2]string{{`"`, `"`}, {"“", "”"}, {"「", "」"}, {"『", "』"}, {"'", "'"}, {"«", "»"}}
+
+// CleanTranslation 去掉模型添加的前置说明和包裹整段译文的引号
+// 原文本身以同样的说明或引号开头时保持不变
+func CleanTranslation(source, translated string) string {
+	cleaned := strings.TrimSpace(translated)
+	source = strings.TrimSpace(source)
+
+	if loc := translationPreamble.FindStringIndex(cleaned); loc != nil && !translationPreamble.MatchString(source) {
+		if rest := strings.TrimSpace(cleaned[loc[1]:]); rest != "" {
+			cleaned = rest
+		}
+	}
+
+	for _, pair := range wrappingQuotes {
+		if strings.HasPrefix(cleaned, pair[0]) && strings.HasSuffix(cleaned, pair[1]) && len(cleaned) > len(pair[0])+len(pair[1]) &&
+			!startsWithQuote(source) {
+			inner := cleaned[len(pair[0]) : len(cleaned)-len(pair[1])]
+			// 中间还有同样的引号时，说明不是包裹整段的引号
+			if !strings.Contains(inner, pair[0]) && !strings.Contains(inner, pair[1]) {
+				cleaned = strings.TrimSpace(inner)
+			}
+			break
+		}
+	}
+	return cleaned
+}
+
+// startsWithQuote 判断文本是否以引号开头
+func startsWithQuote(text string) bool {
+	for _, pair := range wrappingQuotes {
+		if strings.HasPrefix(text, pair[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOutput 备用链使用的质量检查：不合格的输出交给下一个提供商
+// 结构化请求通过 TranslateStructured 发送，不经过这里
+func checkOutput(text, targetLanguage, translated string) error {
+	return CheckTranslation(text, CleanTranslation(text, translated), targetLanguage)
+}
@@ -0,0 +1,165 @@
+package translator
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckTranslation(t *testing.T) {
+	long := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 4)
+	tests := []struct {
+		name       string
+		source     string
+		translated string
+		lang       string
+		want       []QualityIssue
+	}{
+		{"ok", "The quick brown fox jumps over the lazy dog.", "敏捷的棕色狐狸跳过了懒狗。", "Chinese", nil},
+		{"unchanged", "The quick brown fox jumps over the lazy dog.", "The quick brown fox jumps over the lazy dog.", "Chinese", []QualityIssue{IssueUnchanged}},
+		{"unchanged latin target", "The quick brown fox jumps over the lazy dog.", "The quick brown fox jumps over the lazy dog.", "French", []QualityIssue{IssueUnchanged}},
+		{"already target language", "敏捷的棕色狐狸跳过了懒狗，然后跑开了。", "敏捷的棕色狐狸跳过了懒狗，然后跑开了。", "zh-CN", nil},
+		{"short text kept", "Harry Potter", "Harry Potter", "Chinese", nil},
+		{"wrong script", "The quick brown fox jumps over the lazy dog.", "Le renard brun rapide saute par-dessus le chien.", "Japanese", []QualityIssue{IssueWrongScript}},
+		{"identifiers in chinese", "Call getUserById to load the UserRepository.", "调用 getUserById 从 UserRepository 中加载数据。", "Chinese", nil},
+		{"truncated", long, "敏捷的", "Chinese", []QualityIssue{IssueLengthRatio}},
+		{"unknown script", "The quick brown fox jumps over the lazy dog.", "Mabilis na kayumangging soro.", "Tagalog", nil},
+	}
+	for _, tt := range tests {
+		err := CheckTranslation(tt.source, tt.translated, tt.lang)
+		var got []QualityIssue
+		var qualityErr *QualityError
+		if errors.As(err, &qualityErr) {
+			got = qualityErr.Issues
+			if !errors.Is(err, ErrQuality) {
+				t.Errorf("%s: expected error to wrap ErrQuality", tt.name)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestCleanTranslation(t *testing.T) {
+	tests := []struct {
+		source, translated, want string
+	}{
+		{"Hello.", "Here is the translation:\n你好。", "你好。"},
+		{"Hello.", "Sure! Here's the Chinese translation: 你好。", "你好。"},
+		{"Hello.", "Translation: 你好。", "你好。"},
+		{"Hello.", "以下是译文：你好。", "你好。"},
+		{"Hello.", "\"你好。\"", "你好。"},
+		{"Hello.", "“你好。”", "你好。"},
+		{"\"Hello,\" he said.", "“你好，”他说。", "“你好，”他说。"},
+		{"Hello and goodbye.", "“你好”和“再见”。", "“你好”和“再见”。"},
+		{"Translation: a guide", "翻译：指南", "翻译：指南"},
+	}
+	for _, tt := range tests {
+		if got := CleanTranslation(tt.source, tt.translated); got != tt.want {
+			t.Errorf("CleanTranslation(%q, %q) = %q, want %q", tt.source, tt.translated, got, tt.want)
+		}
+	}
+}
+
+// sequenceClient 依次返回预设的译文，用完后重复最后一个
+type sequenceClient struct {
+	results []string
+	calls   int
+	prompts []string
+}
+
+func (c *sequenceClient) Translate(text, targetLanguage, userPrompt string) (string, error) {
+	c.prompts = append(c.prompts, userPrompt)
+	result := c.results[min(c.calls, len(c.results)-1)]
+	c.calls++
+	return result, nil
+}
+
+func TestTranslateSegment_RetriesAndFlags(t *testing.T) {
+	source := "The quick brown fox jumps over the lazy dog."
+
+	client := &sequenceClient{results: []string{source, "Here is the translation: 敏捷的棕色狐狸跳过了懒狗。"}}
+	dt := &DocumentTranslator{Client: client, Cache: newTestCache(t)}
+	result, err := dt.translateSegment(source, "Chinese", "")
+	if err != nil {
+		t.Fatalf("translateSegment failed: %v", err)
+	}
+	if result.Text != "敏捷的棕色狐狸跳过了懒狗。" || len(result.Issues) != 0 || client.calls != 2 {
+		t.Errorf("Expected cleaned retry result, got %+v after %d calls", result, client.calls)
+	}
+	if !strings.Contains(client.prompts[1], "rejected") {
+		t.Errorf("Expected retry prompt to explain the rejection, got %q", client.prompts[1])
+	}
+
+	client = &sequenceClient{results: []string{source}}
+	dt.Client = client
+	result, err = dt.translateSegment(source, "Chinese", "")
+	if err != nil {
+		t.Fatalf("translateSegment failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Issues, []QualityIssue{IssueUnchanged}) || client.calls != 1+maxQualityRetries {
+		t.Errorf("Expected flagged result after retries, got %+v after %d calls", result, client.calls)
+	}
+}
+
+func TestTranslateGroup_FallsBackOnQuality(t *testing.T) {
+	source := "The quick brown fox jumps over the lazy dog."
+	dt := &DocumentTranslator{
+		Client: &FallbackClient{
+			Providers: []FallbackProvider{
+				{Name: "a", Client: &staticClient{result: source}},
+				{Name: "b", Client: &staticClient{result: "敏捷的棕色狐狸跳过了懒狗。"}},
+			},
+			Check: checkOutput,
+		},
+		Cache: newTestCache(t),
+	}
+	result := dt.translateGroup([]string{source}, "Chinese", "")[source]
+	if result.Provider != "b" || len(result.Issues) != 0 {
+		t.Errorf("Expected translation from fallback provider b, got %+v", result)
+	}
+}
+
+func TestFallbackClient_StructuredRequests(t *testing.T) {
+	// 以 "[{" 开头的普通段落仍然做质量检查
+	source := "[{1}] The quick brown fox jumps over the lazy dog."
+	if err := checkOutput(source, "Chinese", source); err == nil {
+		t.Error("Expected untranslated output to be rejected")
+	}
+
+	// 批量请求不经过逐段检查，第一个提供商的结果直接交给解析
+	backup := &staticClient{result: "[]"}
+	dt := &DocumentTranslator{
+		Client: &FallbackClient{
+			Providers: []FallbackProvider{
+				{Name: "a", Client: &batchEchoClient{}},
+				{Name: "b", Client: backup},
+			},
+			Check: func(text, targetLanguage, translated string) error { return errors.New("rejected") },
+		},
+	}
+	results, provider, err := dt.translateBatch([]string{"one", "two"}, "Chinese", "")
+	if err != nil {
+		t.Fatalf("translateBatch failed: %v", err)
+	}
+	if provider != "a" || !reflect.DeepEqual(results, []string{"译:one", "译:two"}) || backup.calls != 0 {
+		t.Errorf("Expected unchecked batch from provider a, got %q from %q (backup calls %d)", results, provider, backup.calls)
+	}
+}
+
+func TestLLMClient_Translate_Truncated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"敏捷的棕色"},"finish_reason":"length"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewLLMClient("test-api-key", server.URL, "gpt-3.5-turbo")
+	if _, err := client.Translate("The quick brown fox.", "Chinese", ""); !errors.Is(err, ErrIncompleteOutput) {
+		t.Errorf("Expected ErrIncompleteOutput, got %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package translator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// RateLimit 每分钟请求数与 token 数限制，0 表示不限制
+type RateLimit struct {
+	RequestsPerMinute int `json:"requests_per_minute"`
+	TokensPerMinute   int `json:"tokens_per_minute"`
+}
+
+// Enabled 是否配置了任何限制
+func (l RateLimit) Enabled() bool {
+	return l.RequestsPerMinute > 0 || l.TokensPerMinute > 0
+}
+
+// RateLimiter 基于令牌桶的限流器
+// 额度不足时允许余额为负，后来的请求按顺序排队等待，从而阻塞而不是失败
+type RateLimiter struct {
+	mu       sync.Mutex
+	limit    RateLimit
+	requests float64
+	tokens   float64
+	last     time.Time
+	now      func() time.Time
+}
+
+// NewRateLimiter 创建限流器，初始额度为满
+func NewRateLimiter(limit RateLimit) *RateLimiter {
+	return &RateLimiter{
+		limit:    limit,
+		requests: float64(limit.RequestsPerMinute),
+		tokens:   float64(limit.TokensPerMinute),
+		last:     time.Now(),
+		now:      time.Now,
+	}
+}
+
+// SetLimit 更新限制（同一提供商的新任务可能带来新的配置）
+func (l *RateLimiter) SetLimit(limit RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit == limit {
+		return
+	}
+	l.refill()
+	// 之前不限制的维度从满额度开始，否则保留已消耗的额度
+	if l.limit.RequestsPerMinute == 0 {
+		l.requests = float64(limit.RequestsPerMinute)
+	} else {
+		l.requests = minFloat(l.requests, float64(limit.RequestsPerMinute))
+	}
+	if l.limit.TokensPerMinute == 0 {
+		l.tokens = float64(limit.TokensPerMinute)
+	} else {
+		l.tokens = minFloat(l.tokens, float64(limit.TokensPerMinute))
+	}
+	l.limit = limit
+}
+
+// Reserve 预占一次请求和 tokens 个 token 的额度，返回需要等待的时间
+func (l *RateLimiter) Reserve(tokens int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+
+	var wait time.Duration
+	if l.limit.RequestsPerMinute > 0 {
+		l.requests--
+		wait = maxDuration(wait, deficitWait(l.requests, l.limit.RequestsPerMinute))
+	}
+	if l.limit.TokensPerMinute > 0 {
+		// 单个请求超过整分钟额度时按整分钟额度计算，避免永远等不到
+		if tokens > l.limit.TokensPerMinute {
+			tokens = l.limit.TokensPerMinute
+		}
+		l.tokens -= float64(tokens)
+		wait = maxDuration(wait, deficitWait(l.tokens, l.limit.TokensPerMinute))
+	}
+	return wait
+}
+
+// refill 按经过的时间补充额度，调用方需持有锁
+func (l *RateLimiter) refill() {
+	now := l.now()
+	elapsed := now.Sub(l.last).Minutes()
+	l.last = now
+	if elapsed <= 0 {
+		return
+	}
+	if l.limit.RequestsPerMinute > 0 {
+		l.requests = minFloat(l.requests+elapsed*float64(l.limit.RequestsPerMinute), float64(l.limit.RequestsPerMinute))
+	}
+	if l.limit.TokensPerMinute > 0 {
+		l.tokens = minFloat(l.tokens+elapsed*float64(l.limit.TokensPerMinute), float64(l.limit.TokensPerMinute))
+	}
+}
+
+// deficitWait 计算余额补足到 0 所需的时间
+func deficitWait(balance float64, perMinute int) time.Duration {
+	if balance >= 0 {
+		return 0
+	}
+	return time.Duration(-balance / float64(perMinute) * float64(time.Minute))
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// rateLimiters 进程内共享的限流器，同一提供商账号的所有任务共用一份额度
+var rateLimiters = struct {
+	mu sync.Mutex
+	m  map[string]*RateLimiter
+}{m: make(map[string]*RateLimiter)}
+
+// SharedRateLimiter 获取（或创建）指定键的共享限流器
+func SharedRateLimiter(key string, limit RateLimit) *RateLimiter {
+	rateLimiters.mu.Lock()
+	defer rateLimiters.mu.Unlock()
+
+	if limiter, ok := rateLimiters.m[key]; ok {
+		limiter.SetLimit(limit)
+		return limiter
+	}
+	limiter := NewRateLimiter(limit)
+	rateLimiters.m[key] = limiter
+	return limiter
+}
+
+// RateLimitKey 生成提供商账号的限流键（API Key 只保留哈希）
+func RateLimitKey(config ProviderConfig) string {
+	hash := sha256.Sum256([]byte(config.APIKey))
+	return string(config.Type) + "|" + config.APIURL + "|" + config.Model + "|" + hex.EncodeToString(hash[:8])
+}
+
+// RateLimitedClient 为任意翻译客户端增加限流
+// 同一任务的各工作协程共用一个客户端，排队状态按所有正在排队的请求汇总后报告
+type RateLimitedClient struct {
+	Client  TranslatorClientInterface
+	Limiter *RateLimiter
+	// OnWait 有请求开始排队时以所有排队请求中最晚的剩余等待时间调用，最后一个排队的请求结束后以 0 调用
+	OnWait func(time.Duration)
+
+	mu sync.Mutex
+	// waiters 正在排队的请求数，deadline 其中最晚的结束时间
+	waiters  int
+	deadline time.Time
+	sleep    func(time.Duration)
+}
+
+// NewRateLimitedClient 创建带限流的翻译客户端
+func NewRateLimitedClient(client TranslatorClientInterface, limiter *RateLimiter) *RateLimitedClient {
+	return &RateLimitedClient{
+		Client:  client,
+		Limiter: limiter,
+		sleep:   time.Sleep,
+	}
+}
+
+// Translate 等待额度后再翻译文本
+func (c *RateLimitedClient) Translate(text, targetLanguage, userPrompt string) (string, error) {
+	// 输出长度按与输入相当估算，再加上系统提示词的开销
+	estimated := 2*(EstimateTokens(text)+EstimateTokens(userPrompt)) + 50
+
+	if wait := c.Limiter.Reserve(estimated); wait > 0 {
+		c.beginWait(wait)
+		c.sleep(wait)
+		c.endWait()
+	}
+
+	return c.Client.Translate(text, targetLanguage, userPrompt)
+}
+
+// beginWait 记录一个开始排队的请求，并报告最晚的剩余等待时间
+// 回调在锁内进行，保证报告的顺序与状态变化一致
+func (c *RateLimitedClient) beginWait(wait time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waiters++
+	if until := time.Now().Add(wait); until.After(c.deadline) {
+		c.deadline = until
+	}
+	if c.OnWait != nil {
+		c.OnWait(time.Until(c.deadline))
+	}
+}
+
+// endWait 记录一个排队结束的请求，没有请求在排队时报告 0
+func (c *RateLimitedClient) endWait() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waiters--
+	if c.waiters > 0 {
+		return
+	}
+	c.deadline = time.Time{}
+	if c.OnWait != nil {
+		c.OnWait(0)
+	}
+}
@@ -0,0 +1,112 @@
+package translator
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Reserve(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(RateLimit{RequestsPerMinute: 60, TokensPerMinute: 1000})
+	limiter.now = func() time.Time { return now }
+	limiter.last = now
+
+	// 满额度内不需要等待
+	for i := 0; i < 60; i++ {
+		if wait := limiter.Reserve(10); wait != 0 {
+			t.Fatalf("Request %d: expected no wait, got %s", i, wait)
+		}
+	}
+
+	// 第 61 个请求需要等 1 秒补充一次请求额度
+	if wait := limiter.Reserve(10); wait != time.Second {
+		t.Errorf("Expected 1s wait for request budget, got %s", wait)
+	}
+
+	// 时间推进一分钟后额度恢复
+	now = now.Add(time.Minute)
+	if wait := limiter.Reserve(10); wait != 0 {
+		t.Errorf("Expected no wait after refill, got %s", wait)
+	}
+
+	// token 额度不足：余额 1000-10=990，再预占 1000（按上限计）后欠 10 个 token
+	if wait := limiter.Reserve(5000); wait != 600*time.Millisecond {
+		t.Errorf("Expected 600ms wait for token budget, got %s", wait)
+	}
+}
+
+func TestSharedRateLimiter(t *testing.T) {
+	config := ProviderConfig{Type: ProviderOpenAI, APIKey: "key", APIURL: "https://example.com", Model: "m"}
+	key := RateLimitKey(config)
+
+	a := SharedRateLimiter(key, RateLimit{RequestsPerMinute: 10})
+	b := SharedRateLimiter(key, RateLimit{RequestsPerMinute: 20})
+	if a != b {
+		t.Fatal("Expected the same limiter for the same provider key")
+	}
+	if a.limit.RequestsPerMinute != 20 {
+		t.Errorf("Expected limit to be updated to 20, got %d", a.limit.RequestsPerMinute)
+	}
+
+	other := ProviderConfig{Type: ProviderOpenAI, APIKey: "other", APIURL: "https://example.com", Model: "m"}
+	if SharedRateLimiter(RateLimitKey(other), RateLimit{RequestsPerMinute: 10}) == a {
+		t.Error("Expected a different limiter for a different API key")
+	}
+}
+
+func TestRateLimitedClient_OnWait(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(RateLimit{RequestsPerMinute: 60})
+	limiter.now = func() time.Time { return now }
+	limiter.last = now
+	for i := 0; i < 60; i++ {
+		limiter.Reserve(0)
+	}
+
+	client := NewRateLimitedClient(&staticClient{result: "ok"}, limiter)
+	var (
+		mu      sync.Mutex
+		reports []time.Duration
+	)
+	client.OnWait = func(wait time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, wait)
+	}
+	// 等待 1 秒的请求先结束，等待 2 秒的请求仍在排队
+	release := map[time.Duration]chan struct{}{time.Second: make(chan struct{}), 2 * time.Second: make(chan struct{})}
+	sleeping := make(chan time.Duration)
+	client.sleep = func(d time.Duration) {
+		sleeping <- d
+		<-release[d]
+	}
+
+	done := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		go func() {
+			client.Translate("text", "Chinese", "")
+			done <- struct{}{}
+		}()
+		<-sleeping
+	}
+
+	release[time.Second] <- struct{}{}
+	<-done
+	mu.Lock()
+	for _, wait := range reports {
+		if wait == 0 {
+			t.Errorf("Expected no zero report while a request is still queued, got %v", reports)
+		}
+	}
+	if last := reports[len(reports)-1]; last <= time.Second {
+		t.Errorf("Expected the latest deadline to be reported, got %v", reports)
+	}
+	mu.Unlock()
+
+	release[2*time.Second] <- struct{}{}
+	<-done
+	if last := reports[len(reports)-1]; last != 0 || len(reports) != 3 {
+		t.Errorf("Expected a single zero report after the last request, got %v", reports)
+	}
+}
@@ -0,0 +1,223 @@
+package translator
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy 重试策略
+type RetryPolicy struct {
+	// MaxAttempts 单个请求最多尝试的次数（含首次请求）
+	MaxAttempts int
+	// BaseDelay 指数退避的初始等待时间
+	BaseDelay time.Duration
+	// MaxDelay 指数退避的最长等待时间（服务端明确要求的等待时间不受此限制）
+	MaxDelay time.Duration
+	// Budget 整个任务允许的重试总次数，<= 0 表示不限制
+	Budget int
+}
+
+// DefaultRetryPolicy 默认重试策略
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Second,
+		MaxDelay:    time.Minute,
+		Budget:      200,
+	}
+}
+
+// RetryPolicyFromConfig 从 ProviderConfig.Extra 读取重试策略
+// 支持的 Extra 参数: max_retries, retry_base_delay_ms, retry_max_delay_ms, retry_budget
+func RetryPolicyFromConfig(config ProviderConfig) RetryPolicy {
+	policy := DefaultRetryPolicy()
+	if v, ok := config.ExtraInt("max_retries"); ok && v >= 0 {
+		policy.MaxAttempts = v + 1
+	}
+	if v, ok := config.ExtraInt("retry_base_delay_ms"); ok && v > 0 {
+		policy.BaseDelay = time.Duration(v) * time.Millisecond
+	}
+	if v, ok := config.ExtraInt("retry_max_delay_ms"); ok && v > 0 {
+		policy.MaxDelay = time.Duration(v) * time.Millisecond
+	}
+	if v, ok := config.ExtraInt("retry_budget"); ok {
+		policy.Budget = v
+	}
+	return policy
+}
+
+// RetryEvent 一次重试的信息
+type RetryEvent struct {
+	// Attempt 即将进行的是第几次尝试（从 2 开始）
+	Attempt int
+	// Delay 本次重试前的等待时间
+	Delay time.Duration
+	// Err 导致重试的错误
+	Err error
+	// Used 任务累计已使用的重试次数
+	Used int
+}
+
+// RetryClient 为任意翻译客户端增加重试能力
+type RetryClient struct {
+	Client  TranslatorClientInterface
+	Policy  RetryPolicy
+	OnRetry func(RetryEvent)
+
+	used  int64
+	sleep func(time.Duration)
+}
+
+// NewRetryClient 创建带重试的翻译客户端
+func NewRetryClient(client TranslatorClientInterface, policy RetryPolicy) *RetryClient {
+	return &RetryClient{
+		Client: client,
+		Policy: policy,
+		sleep:  time.Sleep,
+	}
+}
+
+// Translate 翻译文本，遇到限流或服务端错误时按策略重试
+func (c *RetryClient) Translate(text, targetLanguage, userPrompt string) (string, error) {
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		translated, err := c.Client.Translate(text, targetLanguage, userPrompt)
+		if err == nil {
+			return translated, nil
+		}
+		lastErr = err
+
+		if attempt >= c.Policy.MaxAttempts || !isRetryableError(err) {
+			return "", lastErr
+		}
+
+		// 任务级别的重试预算
+		used := int(atomic.AddInt64(&c.used, 1))
+		if c.Policy.Budget > 0 && used > c.Policy.Budget {
+			return "", lastErr
+		}
+
+		delay := c.Policy.backoff(attempt, err)
+		if c.OnRetry != nil {
+			c.OnRetry(RetryEvent{Attempt: attempt + 1, Delay: delay, Err: err, Used: used})
+		}
+		c.sleep(delay)
+	}
+}
+
+// Used 返回任务累计已使用的重试次数
+func (c *RetryClient) Used() int {
+	return int(atomic.LoadInt64(&c.used))
+}
+
+// backoff 计算第 attempt 次失败后的等待时间
+func (p RetryPolicy) backoff(attempt int, err error) time.Duration {
+	// 优先遵循服务端给出的等待时间，额外加一点抖动避免多个请求同时醒来
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if wait := parseRetryAfter(apiErr.Header, time.Now()); wait > 0 {
+			return wait + time.Duration(rand.Int63n(int64(250*time.Millisecond)))
+		}
+	}
+
+	// 指数退避 + 抖动：在 [d/2, d] 之间随机
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// isRetryableError 判断错误是否值得重试
+func isRetryableError(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusRequestTimeout,
+			apiErr.StatusCode == http.StatusTooManyRequests,
+			apiErr.StatusCode >= 500:
+			// 包括 Anthropic 的 529 overloaded
+			return true
+		}
+		return false
+	}
+
+	// 网络层错误（超时、连接被重置等）
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// parseRetryAfter 从响应头解析服务端建议的等待时间，没有则返回 0
+func parseRetryAfter(header http.Header, now time.Time) time.Duration {
+	if header == nil {
+		return 0
+	}
+
+	// retry-after-ms（OpenAI / Azure）
+	if v := header.Get("Retry-After-Ms"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms > 0 {
+			return time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+
+	// Retry-After: 秒数或 HTTP 日期
+	if v := header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+			return time.Duration(secs * float64(time.Second))
+		}
+		if t, err := http.ParseTime(v); err == nil && t.After(now) {
+			return t.Sub(now)
+		}
+	}
+
+	// x-ratelimit-reset-requests / x-ratelimit-reset-tokens（OpenAI 格式如 "1s"、"6m0s"）
+	// anthropic-ratelimit-requests-reset 等（RFC 3339 时间戳）
+	// 优先使用已耗尽（remaining 为 0）的那一项
+	var exhausted, shortest time.Duration
+	for key, values := range header {
+		lower := strings.ToLower(key)
+		if !strings.Contains(lower, "ratelimit") || !strings.Contains(lower, "reset") || len(values) == 0 {
+			continue
+		}
+		wait := parseResetValue(values[0], now)
+		if wait <= 0 {
+			continue
+		}
+		if shortest == 0 || wait < shortest {
+			shortest = wait
+		}
+		remainingKey := strings.Replace(lower, "reset", "remaining", 1)
+		if header.Get(remainingKey) == "0" && wait > exhausted {
+			exhausted = wait
+		}
+	}
+	if exhausted > 0 {
+		return exhausted
+	}
+	return shortest
+}
+
+// parseResetValue 解析限流重置时间：时长字符串、秒数或 RFC 3339 时间戳
+func parseResetValue(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if d, err := time.ParseDuration(v); err == nil {
+		return d
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,127 @@
+package translator
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// flakyClient 前 failures 次返回指定错误，之后返回成功
+type flakyClient struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (c *flakyClient) Translate(text, targetLanguage, userPrompt string) (string, error) {
+	c.calls++
+	if c.calls <= c.failures {
+		return "", c.err
+	}
+	return "translated:" + text, nil
+}
+
+func TestRetryClient_RetriesRateLimit(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "2")
+	inner := &flakyClient{failures: 2, err: &APIError{StatusCode: http.StatusTooManyRequests, Header: header}}
+
+	client := NewRetryClient(inner, DefaultRetryPolicy())
+	var slept []time.Duration
+	client.sleep = func(d time.Duration) { slept = append(slept, d) }
+	var events []RetryEvent
+	client.OnRetry = func(e RetryEvent) { events = append(events, e) }
+
+	result, err := client.Translate("Hello", "Chinese", "")
+	if err != nil {
+		t.Fatalf("Translate failed: %v", err)
+	}
+	if result != "translated:Hello" {
+		t.Errorf("Expected 'translated:Hello', got '%s'", result)
+	}
+	if inner.calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", inner.calls)
+	}
+	if len(events) != 2 || events[1].Attempt != 3 || events[1].Used != 2 {
+		t.Errorf("Unexpected retry events: %+v", events)
+	}
+	for _, d := range slept {
+		if d < 2*time.Second {
+			t.Errorf("Expected delay to honor Retry-After of 2s, got %s", d)
+		}
+	}
+}
+
+func TestRetryClient_DoesNotRetryClientErrors(t *testing.T) {
+	inner := &flakyClient{failures: 1, err: &APIError{StatusCode: http.StatusBadRequest}}
+	client := NewRetryClient(inner, DefaultRetryPolicy())
+	client.sleep = func(time.Duration) {}
+
+	if _, err := client.Translate("Hello", "Chinese", ""); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if inner.calls != 1 {
+		t.Errorf("Expected 1 call, got %d", inner.calls)
+	}
+}
+
+func TestRetryClient_Budget(t *testing.T) {
+	inner := &flakyClient{failures: 100, err: &APIError{StatusCode: http.StatusServiceUnavailable}}
+	client := NewRetryClient(inner, RetryPolicy{MaxAttempts: 10, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, Budget: 3})
+	client.sleep = func(time.Duration) {}
+
+	if _, err := client.Translate("a", "Chinese", ""); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if _, err := client.Translate("b", "Chinese", ""); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	// 第一次请求用完 3 次预算（共 4 次调用），第二次请求不再重试
+	if inner.calls != 5 {
+		t.Errorf("Expected 5 calls, got %d", inner.calls)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   time.Duration
+	}{
+		{"seconds", map[string]string{"Retry-After": "3"}, 3 * time.Second},
+		{"http date", map[string]string{"Retry-After": now.Add(10 * time.Second).Format(http.TimeFormat)}, 10 * time.Second},
+		{"milliseconds", map[string]string{"retry-after-ms": "1500"}, 1500 * time.Millisecond},
+		{"openai exhausted tokens", map[string]string{
+			"x-ratelimit-reset-requests":     "1s",
+			"x-ratelimit-remaining-requests": "10",
+			"x-ratelimit-reset-tokens":       "6m0s",
+			"x-ratelimit-remaining-tokens":   "0",
+		}, 6 * time.Minute},
+		{"anthropic timestamp", map[string]string{
+			"anthropic-ratelimit-requests-reset": now.Add(30 * time.Second).Format(time.RFC3339),
+		}, 30 * time.Second},
+		{"none", map[string]string{}, 0},
+	}
+
+	for _, tt := range tests {
+		header := http.Header{}
+		for k, v := range tt.header {
+			header.Set(k, v)
+		}
+		if got := parseRetryAfter(header, now); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	if !isRetryableError(fmt.Errorf("wrapped: %w", &APIError{StatusCode: 529})) {
+		t.Error("Expected 529 to be retryable")
+	}
+	if isRetryableError(&APIError{StatusCode: http.StatusUnauthorized}) {
+		t.Error("Expected 401 not to be retryable")
+	}
+}
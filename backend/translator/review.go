@@ -0,0 +1,84 @@
+package translator
+
+import "log"
+
+// reviewInstruction 审校说明，初译放在 <draft> 中随系统提示词发送
+const reviewInstruction = `Between <draft> and </draft> is a draft translation of the text you are given. Review it against the source text:
+fix mistranslations, omissions, additions, inconsistent terminology and awkward phrasing, and keep everything that is already correct.
+Return only the corrected translation of the full text, without comments or a list of changes. If the draft needs no changes, return it as is.`
+
+// ReviewDraft 审校记录：同一文本块的初译和审校后的译文
+type ReviewDraft struct {
+	Draft    string `json:"draft"`
+	Reviewed string `json:"reviewed"`
+	// Provider 产生初译的提供商，Reviewer 产生审校译文的提供商
+	Provider string `json:"provider,omitempty"`
+	Reviewer string `json:"reviewer,omitempty"`
+}
+
+// EnableReview 启用审校：每段初译后把原文和初译交给 config 指定的提供商（链）修改，可以与初译使用不同的模型
+func (dt *DocumentTranslator) EnableReview(config ProviderConfig) error {
+	client, label, err := dt.newChainClient(config)
+	if err != nil {
+		return err
+	}
+	dt.Reviewer = client
+	dt.ReviewProvider = label
+	return nil
+}
+
+// Drafts 返回最近一次翻译中审校过的文本块（原文 -> 初译和审校译文）
+func (dt *DocumentTranslator) Drafts() map[string]ReviewDraft {
+	return dt.drafts
+}
+
+// reviewPrompt 把初译附加到提示词之后
+func reviewPrompt(prompt, draft string) string {
+	return joinPrompt(prompt, reviewInstruction+"\n<draft>\n"+draft+"\n</draft>")
+}
+
+// reviewResults 审校一组初译，results 中审校成功的译文替换为审校后的译文
+// 初译以不含审校的缓存键写入缓存；返回审校成功的文本块的记录，审校失败的保留初译
+func (dt *DocumentTranslator) reviewResults(texts, prompts []string, targetLanguage string, results map[string]segmentResult) map[string]ReviewDraft {
+	drafts := make(map[string]ReviewDraft)
+	reviewed := make(map[string]bool) // 同一组中重复的原文只审校一次
+	for i, text := range texts {
+		draft, ok := results[text]
+		if !ok || reviewed[text] {
+			continue
+		}
+		reviewed[text] = true
+		dt.Cache.SetEntry(CacheKey(text, targetLanguage, prompts[i], dt.Provider), draft.Text, draft.Provider)
+
+		reviewed, reviewer, err := dt.reviewSegment(text, draft.Text, targetLanguage, prompts[i])
+		if err != nil {
+			log.Printf("审校失败，保留初译: %v", err)
+			continue
+		}
+		drafts[text] = ReviewDraft{Draft: draft.Text, Reviewed: reviewed, Provider: draft.Provider, Reviewer: reviewer}
+		// 审校译文通过了质量检查，初译的问题视为已修正
+		results[text] = segmentResult{Text: reviewed, Provider: draft.Provider}
+	}
+	return drafts
+}
+
+// reviewSegment 审校单个文本块的初译，返回审校后的译文和审校提供商
+// 审校结果按审校提示词（包含初译）缓存；未通过质量检查的审校译文视为失败
+func (dt *DocumentTranslator) reviewSegment(text, draft, targetLanguage, prompt string) (string, string, error) {
+	prompt = reviewPrompt(prompt, draft)
+	key := CacheKey(text, targetLanguage, prompt, dt.ReviewProvider)
+	if cached, ok := dt.Cache.GetEntry(key); ok {
+		return cached.Value, cached.Provider, nil
+	}
+
+	reviewed, reviewer, err := translateAttributed(dt.Reviewer, dt.ReviewProvider, text, targetLanguage, prompt)
+	if err != nil {
+		return "", "", err
+	}
+	reviewed = CleanTranslation(text, reviewed)
+	if err := CheckTranslation(text, reviewed, targetLanguage); err != nil {
+		return "", "", err
+	}
+	dt.Cache.SetEntry(key, reviewed, reviewer)
+	return reviewed, reviewer, nil
+}
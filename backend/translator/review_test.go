@@ -0,0 +1,98 @@
+package translator
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// reviewClient 审校客户端：在 pseudoTranslate(原文) 后加上句号作为修改后的译文
+type reviewClient struct {
+	mu      sync.Mutex
+	prompts map[string]string
+}
+
+func (c *reviewClient) Translate(text, targetLanguage, userPrompt string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.prompts == nil {
+		c.prompts = make(map[string]string)
+	}
+	c.prompts[text] = userPrompt
+	return pseudoTranslate(text) + "。", nil
+}
+
+func TestTranslateEPUB_Review(t *testing.T) {
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  testOPF,
+		"OEBPS/text/a.xhtml": testChapter("Second paragraph."),
+		"OEBPS/text/b.xhtml": testChapter("First paragraph."),
+	})
+
+	reviewer := &reviewClient{}
+	cache := newTestCache(t)
+	dt := &DocumentTranslator{
+		Client:         &recordingClient{},
+		Cache:          cache,
+		Provider:       "test/draft",
+		Reviewer:       reviewer,
+		ReviewProvider: "test/review",
+	}
+
+	var progress []float64
+	output := filepath.Join(t.TempDir(), "out.epub")
+	if _, err := dt.TranslateEPUB("review", path, output, "Chinese", "", "bilingual", func(p float64) { progress = append(progress, p) }, nil); err != nil {
+		t.Fatalf("TranslateEPUB failed: %v", err)
+	}
+
+	draft := pseudoTranslate("First paragraph.")
+	if prompt := reviewer.prompts["First paragraph."]; !strings.Contains(prompt, "<draft>\n"+draft+"\n</draft>") {
+		t.Errorf("Expected draft in review prompt, got %q", prompt)
+	}
+
+	// 初译和审校译文分别缓存
+	if got, _ := cache.Get(CacheKey("First paragraph.", "Chinese", "", "test/draft")); got != draft {
+		t.Errorf("Expected draft cached under the first-pass key, got %q", got)
+	}
+	if got, _ := cache.Get(dt.cacheKey("First paragraph.", "Chinese", "")); got != draft+"。" {
+		t.Errorf("Expected reviewed translation cached under the review key, got %q", got)
+	}
+
+	drafts, err := cache.LoadDraftMap("review")
+	if err != nil {
+		t.Fatalf("LoadDraftMap failed: %v", err)
+	}
+	want := ReviewDraft{Draft: draft, Reviewed: draft + "。", Provider: "test/draft", Reviewer: "test/review"}
+	if drafts["First paragraph."] != want {
+		t.Errorf("Expected %+v, got %+v", want, drafts["First paragraph."])
+	}
+
+	// 两个文本块各两步
+	if len(progress) != 4 || progress[0] != 0.25 || progress[3] != 1 {
+		t.Errorf("Expected progress to count both passes, got %v", progress)
+	}
+}
+
+func TestTranslateText_ReviewFailureKeepsDraft(t *testing.T) {
+	dt := &DocumentTranslator{
+		Client:         &recordingClient{},
+		Cache:          newTestCache(t),
+		Provider:       "test/draft",
+		Reviewer:       &staticClient{err: errors.New("boom")},
+		ReviewProvider: "test/review",
+	}
+
+	got, err := dt.TranslateText("Hello there.", "Chinese", "")
+	if err != nil {
+		t.Fatalf("TranslateText failed: %v", err)
+	}
+	if got != pseudoTranslate("Hello there.") {
+		t.Errorf("Expected draft translation, got %q", got)
+	}
+	// 审校失败的结果不写入审校缓存，下次会重新审校
+	if _, ok := dt.Cache.Get(dt.cacheKey("Hello there.", "Chinese", "")); ok {
+		t.Error("Expected no cache entry under the review key")
+	}
+}
@@ -0,0 +1,100 @@
+package translator
+
+import "strconv"
+
+// SegmentID 文本块的稳定 ID，插入译文时按 ID 定位
+type SegmentID string
+
+// Segment 文档中的一个文本块
+type Segment struct {
+	// ID 稳定 ID：所在文件、容纳文本块的元素在 body 中的路径和元素内的序号，如 "OEBPS/text/ch1.xhtml#3.1:0"
+	// 同一输入文件每次得到相同的 ID，插入译文时按 ID 定位，不再按原文匹配
+	ID SegmentID `json:"id"`
+	// File 所在的 HTML 文件
+	File string `json:"file"`
+	Text string `json:"text"`
+	// Chapter 所在章节的标题
+	Chapter string `json:"chapter,omitempty"`
+	// Context 容纳文本块的元素，如 p、h1、li、span
+	Context string `json:"context,omitempty"`
+}
+
+// segmentTexts 返回各文本块的原文
+func segmentTexts(segments []Segment) []string {
+	texts := make([]string, len(segments))
+	for i, seg := range segments {
+		texts[i] = seg.Text
+	}
+	return texts
+}
+
+// segmentTranslations 把按翻译单元下标记录的译文展开为按文本块 ID 记录的译文，供插入使用
+// 翻译单元与 splitLongBlocks 的拆分结果一一对应；拆分翻译的文本块拼接各部分的译文，
+// 有部分缺少译文或各部分都已是目标语言（skipped）时不插入
+func segmentTranslations(segments []Segment, parts map[string][]string, translations map[int]string, skipped map[string]bool) map[SegmentID]string {
+	byID := make(map[SegmentID]string, len(segments))
+	pos := 0
+	for _, seg := range segments {
+		blockParts, ok := parts[seg.Text]
+		if !ok {
+			if translated, ok := translations[pos]; ok {
+				byID[seg.ID] = translated
+			}
+			pos++
+			continue
+		}
+
+		untranslated := true
+		for _, part := range blockParts {
+			if !skipped[part] {
+				untranslated = false
+				break
+			}
+		}
+		if !untranslated {
+			if joined, ok := joinTranslatedParts(translations, pos, len(blockParts)); ok {
+				byID[seg.ID] = joined
+			}
+		}
+		pos += len(blockParts)
+	}
+	return byID
+}
+
+// progressKeys 返回各翻译单元的进度键：未拆分的文本块为其 ID，拆分出的部分为 "ID/序号"
+func progressKeys(segments []Segment, parts map[string][]string) []string {
+	keys := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		blockParts, ok := parts[seg.Text]
+		if !ok {
+			keys = append(keys, string(seg.ID))
+			continue
+		}
+		for i := range blockParts {
+			keys = append(keys, string(seg.ID)+"/"+strconv.Itoa(i))
+		}
+	}
+	return keys
+}
+
+// progressSnapshot 把按翻译单元下标记录的译文转为按进度键保存的进度
+func progressSnapshot(translations map[int]string, keys []string) map[string]string {
+	snapshot := make(map[string]string, len(translations))
+	for pos, translated := range translations {
+		if pos < len(keys) {
+			snapshot[keys[pos]] = translated
+		}
+	}
+	return snapshot
+}
+
+// restoreProgress 从按进度键保存的进度恢复按翻译单元下标记录的译文
+func restoreProgress(saved map[string]string, keys []string) map[int]string {
+	translations := make(map[int]string)
+	for pos, key := range keys {
+		if translated, ok := saved[key]; ok {
+			translations[pos] = translated
+		}
+	}
+	return translations
+}
@@ -0,0 +1,132 @@
+package translator
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEPUBFile_GetSegments(t *testing.T) {
+	chapter := `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title>t</title></head><body>
+<h1>Notes</h1>
+<div><p>Notes</p><p>See the <span>Notes</span> above.</p></div>
+</body></html>`
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  testOPF,
+		"OEBPS/text/a.xhtml": testChapter("Chapter A text."),
+		"OEBPS/text/b.xhtml": chapter,
+	})
+
+	epub, err := OpenEPUB(path)
+	if err != nil {
+		t.Fatalf("OpenEPUB failed: %v", err)
+	}
+	segments := epub.GetSegments()
+	want := []Segment{
+		{ID: "OEBPS/text/b.xhtml#0:0", File: "OEBPS/text/b.xhtml", Text: "Notes", Chapter: "Notes", Context: "h1"},
+		{ID: "OEBPS/text/b.xhtml#1.0:0", File: "OEBPS/text/b.xhtml", Text: "Notes", Chapter: "Notes", Context: "p"},
+		{ID: "OEBPS/text/b.xhtml#1.1:0", File: "OEBPS/text/b.xhtml", Text: "See the", Chapter: "Notes", Context: "p"},
+		{ID: "OEBPS/text/b.xhtml#1.1.0:0", File: "OEBPS/text/b.xhtml", Text: "Notes", Chapter: "Notes", Context: "span"},
+		{ID: "OEBPS/text/b.xhtml#1.1:1", File: "OEBPS/text/b.xhtml", Text: "above.", Chapter: "Notes", Context: "p"},
+		{ID: "OEBPS/text/a.xhtml#0:0", File: "OEBPS/text/a.xhtml", Text: "Chapter A text.", Chapter: "t", Context: "p"},
+	}
+	if !reflect.DeepEqual(segments, want) {
+		t.Fatalf("Unexpected segments:\n got: %+v\nwant: %+v", segments, want)
+	}
+
+	// 再次打开得到相同的 ID
+	again, err := OpenEPUB(path)
+	if err != nil {
+		t.Fatalf("OpenEPUB failed: %v", err)
+	}
+	if !reflect.DeepEqual(again.GetSegments(), segments) {
+		t.Error("Expected the same segment IDs after reopening")
+	}
+
+	// 相同的原文按 ID 插入不同的译文
+	translations := map[SegmentID]string{
+		"OEBPS/text/b.xhtml#0:0":     "注释",
+		"OEBPS/text/b.xhtml#1.0:0":   "笔记",
+		"OEBPS/text/b.xhtml#1.1.0:0": "音符",
+	}
+	if err := epub.InsertMonolingualTranslation(translations); err != nil {
+		t.Fatalf("InsertMonolingualTranslation failed: %v", err)
+	}
+	out := string(epub.Files["OEBPS/text/b.xhtml"])
+	for _, want := range []string{"<h1>注释</h1>", "<p>笔记</p>", "<p>See the <span>音符</span> above.</p>"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output, got %s", want, out)
+		}
+	}
+}
+
+func TestProgressKeys(t *testing.T) {
+	segments := []Segment{
+		{ID: "a#0:0", Text: "Same"},
+		{ID: "b#0:0", Text: "Same"},
+		{ID: "b#1:0", Text: "Long. Block."},
+	}
+	parts := map[string][]string{"Long. Block.": {"Long.", "Block."}}
+
+	keys := progressKeys(segments, parts)
+	want := []string{"a#0:0", "b#0:0", "b#1:0/0", "b#1:0/1"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("Expected %v, got %v", want, keys)
+	}
+
+	// 相同原文的文本块各自保存译文
+	snapshot := progressSnapshot(map[int]string{0: "同", 1: "相同", 2: "长。"}, keys)
+	if len(snapshot) != 3 || snapshot["a#0:0"] != "同" || snapshot["b#0:0"] != "相同" || snapshot["b#1:0/0"] != "长。" {
+		t.Errorf("Unexpected snapshot %v", snapshot)
+	}
+	restored := restoreProgress(snapshot, keys)
+	if !reflect.DeepEqual(restored, map[int]string{0: "同", 1: "相同", 2: "长。"}) {
+		t.Errorf("Unexpected restored progress %v", restored)
+	}
+
+	// 拆分的文本块在各部分都有译文后拼接
+	byID := segmentTranslations(segments, parts, restored, nil)
+	if !reflect.DeepEqual(byID, map[SegmentID]string{"a#0:0": "同", "b#0:0": "相同"}) {
+		t.Errorf("Unexpected segment translations %v", byID)
+	}
+	restored[3] = "块。"
+	if got := segmentTranslations(segments, parts, restored, nil)["b#1:0"]; got != "长。块。" {
+		t.Errorf("Expected joined translation, got %q", got)
+	}
+}
+
+func TestTranslateEPUB_ResumesFromSegmentProgress(t *testing.T) {
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  testOPF,
+		"OEBPS/text/a.xhtml": testChapter("Already done."),
+		"OEBPS/text/b.xhtml": testChapter("Still pending."),
+	})
+
+	cache := newTestCache(t)
+	if err := cache.SaveProgressMap("resume", map[string]string{"OEBPS/text/a.xhtml#0:0": "已完成。"}); err != nil {
+		t.Fatalf("SaveProgressMap failed: %v", err)
+	}
+	client := &recordingClient{}
+	dt := &DocumentTranslator{Client: client, Cache: cache, Provider: "test/model"}
+
+	output := filepath.Join(t.TempDir(), "out.epub")
+	if _, err := dt.TranslateEPUB("resume", path, output, "Chinese", "", "monolingual", nil, nil); err != nil {
+		t.Fatalf("TranslateEPUB failed: %v", err)
+	}
+	if _, ok := client.prompts["Already done."]; ok {
+		t.Error("Expected the saved segment not to be translated again")
+	}
+	if _, ok := client.prompts["Still pending."]; !ok {
+		t.Error("Expected the pending segment to be translated")
+	}
+
+	out, err := OpenEPUB(output)
+	if err != nil {
+		t.Fatalf("OpenEPUB failed: %v", err)
+	}
+	if got := string(out.Files["OEBPS/text/a.xhtml"]); !strings.Contains(got, "<p>已完成。</p>") {
+		t.Errorf("Expected the saved translation to be inserted, got %s", got)
+	}
+}
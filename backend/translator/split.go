@@ -0,0 +1,286 @@
+package translator
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultMaxBlockTokens 单个文本块的默认 token 上限，超过时按句子拆分后分别翻译
+const DefaultMaxBlockTokens = 1500
+
+// sentenceEnders 句末标点；CJK 句末标点后不需要空白
+const (
+	latinSentenceEnders = ".!?…;"
+	cjkSentenceEnders   = "。！？；"
+	// clauseSeparators 单个句子仍然过长时，退而按分句标点拆分
+	clauseSeparators = ",，、:：;；—"
+	// closingMarks 句末标点之后可能紧跟的右引号和右括号
+	closingMarks = "\"'”’)）]」』》"
+)
+
+// abbreviations 以句点结尾但通常不是句末的缩写
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "st": true, "prof": true, "sr": true, "jr": true,
+	"vs": true, "etc": true, "e.g": true, "i.e": true, "no": true, "fig": true, "vol": true, "ch": true,
+}
+
+// maxBlockTokens 返回生效的文本块 token 上限
+func (o TranslateOptions) maxBlockTokens() int {
+	if o.MaxBlockTokens <= 0 {
+		return DefaultMaxBlockTokens
+	}
+	return o.MaxBlockTokens
+}
+
+// BlockTokenLimit 根据提供商（链）的 max_tokens 推算文本块的 token 上限
+// 译文通常不长于原文的两倍，因此取 max_tokens 的一半；都未配置 max_tokens 时返回 0（使用默认值）
+func BlockTokenLimit(config ProviderConfig) int {
+	limit := 0
+	if config.MaxTokens > 0 {
+		limit = min(config.MaxTokens/2, DefaultMaxBlockTokens)
+	}
+	for _, fallback := range config.Fallbacks {
+		if l := BlockTokenLimit(fallback); l > 0 && (limit == 0 || l < limit) {
+			limit = l
+		}
+	}
+	return limit
+}
+
+// splitLongBlocks 把超过 token 上限的文本块按句子拆分
+// 返回的 units 按文档顺序排列，长文本块被替换为其各部分；parts 记录每个被拆分文本块的各部分
+func splitLongBlocks(blocks []string, maxTokens int) (units []string, parts map[string][]string) {
+	parts = make(map[string][]string)
+	for _, block := range blocks {
+		if EstimateTokens(block) <= maxTokens {
+			units = append(units, block)
+			continue
+		}
+		split, ok := parts[block]
+		if !ok {
+			split = SplitText(block, maxTokens)
+			parts[block] = split
+		}
+		units = append(units, split...)
+	}
+	return units, parts
+}
+
+// unitChapters 把与 blocks 对应的章节标题展开为与 splitLongBlocks 的 units 对应；chapters 长度不符时返回 nil
+func unitChapters(blocks, chapters []string, parts map[string][]string) []string {
+	if len(chapters) != len(blocks) {
+		return nil
+	}
+	var expanded []string
+	for i, block := range blocks {
+		n := 1
+		if split, ok := parts[block]; ok {
+			n = len(split)
+		}
+		for j := 0; j < n; j++ {
+			expanded = append(expanded, chapters[i])
+		}
+	}
+	return expanded
+}
+
+// SplitText 在句子边界把文本拆分为不超过 maxTokens 的若干部分
+// 单个句子超过上限时按分句标点拆分，仍然过长时按空白或字符强制拆分
+func SplitText(text string, maxTokens int) []string {
+	if maxTokens <= 0 || EstimateTokens(text) <= maxTokens {
+		return []string{text}
+	}
+
+	var pieces []string
+	for _, sentence := range SplitSentences(text) {
+		if EstimateTokens(sentence) <= maxTokens {
+			pieces = append(pieces, sentence)
+			continue
+		}
+		for _, clause := range splitAfter(sentence, clauseSeparators) {
+			if EstimateTokens(clause) <= maxTokens {
+				pieces = append(pieces, clause)
+				continue
+			}
+			pieces = append(pieces, hardSplit(clause, maxTokens)...)
+		}
+	}
+	return packPieces(pieces, maxTokens)
+}
+
+// SplitSentences 按中英文句末标点拆分句子，句末标点和紧随的右引号留在句中
+// 拉丁句末标点要求后面是空白且下一个词不是小写开头，并跳过常见缩写和数字中的小数点
+func SplitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+
+		cjk := strings.ContainsRune(cjkSentenceEnders, r)
+		if !cjk && !strings.ContainsRune(latinSentenceEnders, r) {
+			continue
+		}
+		// 连续的句末标点（如 ?! 或 ……）和右引号
+		for i < len(text) {
+			next, nextSize := utf8.DecodeRuneInString(text[i:])
+			if !strings.ContainsRune(latinSentenceEnders+cjkSentenceEnders+closingMarks, next) {
+				break
+			}
+			i += nextSize
+		}
+		if !cjk {
+			next, _ := utf8.DecodeRuneInString(text[i:])
+			if i < len(text) && !unicode.IsSpace(next) {
+				continue
+			}
+			if r == '.' && isAbbreviation(text[start:i]) {
+				continue
+			}
+			// 后面是小写字母时不是句末，如 "Is that all?" he asked.
+			if after := strings.TrimLeftFunc(text[i:], unicode.IsSpace); after != "" {
+				if first, _ := utf8.DecodeRuneInString(after); unicode.IsLower(first) {
+					continue
+				}
+			}
+		}
+		if sentence := strings.TrimSpace(text[start:i]); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = i
+	}
+	if rest := strings.TrimSpace(text[start:]); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
+
+// isAbbreviation 判断以句点结尾的文本的最后一个词是否为缩写或单个字母（如姓名缩写 J.）
+func isAbbreviation(text string) bool {
+	text = strings.TrimRight(text, ".")
+	if i := strings.LastIndexFunc(text, unicode.IsSpace); i >= 0 {
+		text = text[i+1:]
+	}
+	text = strings.TrimLeft(text, closingMarks+"\"'“‘(（")
+	if utf8.RuneCountInString(text) == 1 {
+		r, _ := utf8.DecodeRuneInString(text)
+		return unicode.IsUpper(r)
+	}
+	return abbreviations[strings.ToLower(text)]
+}
+
+// splitAfter 在 separators 中的任一字符之后拆分文本
+func splitAfter(text, separators string) []string {
+	var pieces []string
+	start := 0
+	for i, r := range text {
+		if strings.ContainsRune(separators, r) {
+			end := i + utf8.RuneLen(r)
+			if piece := strings.TrimSpace(text[start:end]); piece != "" {
+				pieces = append(pieces, piece)
+			}
+			start = end
+		}
+	}
+	if rest := strings.TrimSpace(text[start:]); rest != "" {
+		pieces = append(pieces, rest)
+	}
+	return pieces
+}
+
+// hardSplit 强制拆分没有标点可用的文本：优先在空白处拆分，CJK 文本按字符拆分
+func hardSplit(text string, maxTokens int) []string {
+	var pieces []string
+	var current strings.Builder
+	for _, word := range splitKeepingCJK(text) {
+		candidate := current.String() + word
+		if current.Len() > 0 && EstimateTokens(candidate) > maxTokens {
+			pieces = append(pieces, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+		current.WriteString(word)
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		pieces = append(pieces, rest)
+	}
+	return pieces
+}
+
+// splitKeepingCJK 把文本切成可以自由拼接的小段：CJK 字符各自一段，其他文字按词切分并保留其后的空白
+func splitKeepingCJK(text string) []string {
+	var pieces []string
+	start := 0
+	for i, r := range text {
+		end := i + utf8.RuneLen(r)
+		switch {
+		case isCJK(r):
+			if start < i {
+				pieces = append(pieces, text[start:i])
+			}
+			pieces = append(pieces, text[i:end])
+			start = end
+		case unicode.IsSpace(r):
+			pieces = append(pieces, text[start:end])
+			start = end
+		}
+	}
+	if start < len(text) {
+		pieces = append(pieces, text[start:])
+	}
+	return pieces
+}
+
+// packPieces 把相邻的小段合并为不超过 maxTokens 的部分
+func packPieces(pieces []string, maxTokens int) []string {
+	var parts []string
+	current := ""
+	for _, piece := range pieces {
+		if current == "" {
+			current = piece
+			continue
+		}
+		joined := joinParts(current, piece)
+		if EstimateTokens(joined) > maxTokens {
+			parts = append(parts, current)
+			current = piece
+			continue
+		}
+		current = joined
+	}
+	if current != "" {
+		parts = append(parts, current)
+	}
+	return parts
+}
+
+// joinParts 拼接两段文本：两侧都是 CJK 字符或全角标点时直接相连，否则用空格分隔
+func joinParts(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	last, _ := utf8.DecodeLastRuneInString(a)
+	first, _ := utf8.DecodeRuneInString(b)
+	if isCJKText(last) && isCJKText(first) {
+		return a + b
+	}
+	return a + " " + b
+}
+
+// isCJKText 判断字符是否为 CJK 文字或全角标点
+func isCJKText(r rune) bool {
+	return isCJK(r) || (r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFFEF)
+}
+
+// joinTranslatedParts 按原顺序拼接从下标 start 开始的 n 个翻译单元的译文，有部分未翻译时返回 false
+func joinTranslatedParts(translations map[int]string, start, n int) (string, bool) {
+	joined := ""
+	for pos := start; pos < start+n; pos++ {
+		translated, ok := translations[pos]
+		if !ok {
+			return "", false
+		}
+		joined = joinParts(joined, translated)
+	}
+	return joined, true
+}
@@ -0,0 +1,120 @@
+package translator
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{
+			"Mr. Smith paid $3.50. \"Is that all?\" he asked! Then he left.",
+			[]string{"Mr. Smith paid $3.50.", "\"Is that all?\" he asked!", "Then he left."},
+		},
+		{
+			"他走了。“真的吗？”她问道。我不知道",
+			[]string{"他走了。", "“真的吗？”", "她问道。", "我不知道"},
+		},
+		{
+			"J. R. R. Tolkien wrote it. See e.g. chapter two.",
+			[]string{"J. R. R. Tolkien wrote it.", "See e.g. chapter two."},
+		},
+	}
+	for _, tt := range tests {
+		if got := SplitSentences(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitSentences(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	sentence := "The quick brown fox jumps over the lazy dog. "
+	text := strings.TrimSpace(strings.Repeat(sentence, 20))
+
+	parts := SplitText(text, 50)
+	if len(parts) < 2 {
+		t.Fatalf("Expected text to be split, got %d parts", len(parts))
+	}
+	for _, part := range parts {
+		if EstimateTokens(part) > 50 {
+			t.Errorf("Part exceeds limit: %d tokens", EstimateTokens(part))
+		}
+		if !strings.HasSuffix(part, "dog.") {
+			t.Errorf("Expected split at sentence boundary, got %q", part)
+		}
+	}
+	if joined := strings.Join(parts, " "); joined != text {
+		t.Errorf("Expected parts to rejoin to the original text")
+	}
+
+	// 没有句末标点的长 CJK 文本按字符强制拆分
+	cjk := strings.Repeat("天", 120)
+	parts = SplitText(cjk, 50)
+	if len(parts) != 3 || strings.Join(parts, "") != cjk {
+		t.Errorf("Expected CJK text to be hard split into 3 parts, got %d", len(parts))
+	}
+
+	if parts := SplitText("Short.", 50); !reflect.DeepEqual(parts, []string{"Short."}) {
+		t.Errorf("Expected short text unchanged, got %q", parts)
+	}
+}
+
+func TestJoinParts(t *testing.T) {
+	if got := joinParts("他走了。", "她来了。"); got != "他走了。她来了。" {
+		t.Errorf("Expected CJK parts joined without space, got %q", got)
+	}
+	if got := joinParts("He left.", "She came."); got != "He left. She came." {
+		t.Errorf("Expected Latin parts joined with space, got %q", got)
+	}
+}
+
+func TestBlockTokenLimit(t *testing.T) {
+	config := ProviderConfig{MaxTokens: 4096, Fallbacks: []ProviderConfig{{MaxTokens: 1000}, {}}}
+	if got := BlockTokenLimit(config); got != 500 {
+		t.Errorf("Expected 500, got %d", got)
+	}
+	if got := BlockTokenLimit(ProviderConfig{}); got != 0 {
+		t.Errorf("Expected 0 without max_tokens, got %d", got)
+	}
+}
+
+func TestTranslateEPUB_SplitsLongBlocks(t *testing.T) {
+	long := strings.TrimSpace(strings.Repeat("The quick brown fox jumps over the lazy dog. ", 10))
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  testOPF,
+		"OEBPS/text/a.xhtml": testChapter("Short paragraph."),
+		"OEBPS/text/b.xhtml": testChapter(long),
+	})
+
+	client := &recordingClient{}
+	cache := newTestCache(t)
+	dt := &DocumentTranslator{Client: client, Cache: cache, Provider: "test/model", Options: TranslateOptions{MaxBlockTokens: 30}}
+	output := filepath.Join(t.TempDir(), "out.epub")
+	if _, err := dt.TranslateEPUB("split", path, output, "Chinese", "", "bilingual", nil, nil); err != nil {
+		t.Fatalf("TranslateEPUB failed: %v", err)
+	}
+
+	if _, ok := client.prompts[long]; ok {
+		t.Error("Expected long block not to be sent as a whole")
+	}
+	for text := range client.prompts {
+		if EstimateTokens(text) > 30 {
+			t.Errorf("Expected every request under the limit, got %d tokens", EstimateTokens(text))
+		}
+	}
+
+	epub, err := OpenEPUB(output)
+	if err != nil {
+		t.Fatalf("OpenEPUB failed: %v", err)
+	}
+	content := string(epub.Files["OEBPS/text/b.xhtml"])
+	want := pseudoTranslate(long)
+	if !strings.Contains(content, want) {
+		t.Errorf("Expected rejoined translation %q in output, got %s", want, content)
+	}
+}
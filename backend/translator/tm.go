@@ -0,0 +1,350 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TM 条目来源
+const (
+	// TMOriginMachine 由 etrans 调用模型翻译得到
+	TMOriginMachine = "machine"
+	// TMOriginImport 从 TMX 导入（通常是人工翻译）
+	TMOriginImport = "tmx"
+
+	// TMProvider 译文来自翻译记忆库时记录的提供商
+	TMProvider = "tm"
+)
+
+// TMUnit 翻译记忆中的一对原文/译文
+type TMUnit struct {
+	Source     string    `json:"source"`
+	Target     string    `json:"target"`
+	SourceLang string    `json:"sourceLang,omitempty"` // 为空表示未知，可匹配任意源语言
+	TargetLang string    `json:"targetLang"`
+	Provider   string    `json:"provider,omitempty"`
+	Model      string    `json:"model,omitempty"`
+	Origin     string    `json:"origin"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// TranslationMemory 翻译记忆库，以 JSON 文件持久化
+// 与 Cache 不同，条目按语言对和规范化的原文索引，可以查看、导入和导出
+type TranslationMemory struct {
+	mu    sync.RWMutex
+	path  string
+	units map[string][]*TMUnit           // 规范化的原文 -> 各语言对的条目
+	index map[string]map[string]struct{} // 词 n-gram -> 包含它的规范化原文，用于模糊匹配
+	dirty bool
+}
+
+// translationMemories 进程内共享的翻译记忆库，同一文件的多个任务共用一个实例
+var translationMemories = struct {
+	mu sync.Mutex
+	m  map[string]*TranslationMemory
+}{m: make(map[string]*TranslationMemory)}
+
+// OpenTranslationMemory 打开（或创建）指定路径的翻译记忆库
+// 同一路径在进程内只加载一次
+func OpenTranslationMemory(path string) (*TranslationMemory, error) {
+	translationMemories.mu.Lock()
+	defer translationMemories.mu.Unlock()
+
+	if tm, ok := translationMemories.m[path]; ok {
+		return tm, nil
+	}
+
+	tm := &TranslationMemory{path: path}
+	tm.init()
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取翻译记忆库失败: %w", err)
+	}
+	if err == nil {
+		var units []*TMUnit
+		if err := json.Unmarshal(data, &units); err != nil {
+			return nil, fmt.Errorf("解析翻译记忆库失败: %w", err)
+		}
+		for _, u := range units {
+			key := tmKey(u.Source)
+			if len(tm.units[key]) == 0 {
+				tm.indexKey(key)
+			}
+			tm.units[key] = append(tm.units[key], u)
+		}
+	}
+
+	translationMemories.m[path] = tm
+	return tm, nil
+}
+
+// NewTranslationMemory 创建不持久化的内存翻译记忆库
+func NewTranslationMemory() *TranslationMemory {
+	tm := &TranslationMemory{}
+	tm.init()
+	return tm
+}
+
+func (tm *TranslationMemory) init() {
+	tm.units = make(map[string][]*TMUnit)
+	tm.index = make(map[string]map[string]struct{})
+}
+
+// Len 返回条目数
+func (tm *TranslationMemory) Len() int {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	n := 0
+	for _, units := range tm.units {
+		n += len(units)
+	}
+	return n
+}
+
+// Lookup 查找原文的精确匹配（原文按空白规范化），tm 为空时总是未命中
+// 导入的人工译文优先于机器译文，其次优先语言代码完全一致的条目；源语言未知的条目可匹配任意源语言
+func (tm *TranslationMemory) Lookup(source, sourceLang, targetLang string) (TMUnit, bool) {
+	if tm == nil {
+		return TMUnit{}, false
+	}
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	sourceLang = NormalizeLanguage(sourceLang)
+	targetLang = NormalizeLanguage(targetLang)
+
+	var best *TMUnit
+	bestScore := -1
+	for _, u := range tm.units[tmKey(source)] {
+		if !sameLanguage(u.TargetLang, targetLang) {
+			continue
+		}
+		if sourceLang != "" && u.SourceLang != "" && !sameLanguage(u.SourceLang, sourceLang) {
+			continue
+		}
+		score := 0
+		if u.Origin == TMOriginImport {
+			score += 4
+		}
+		if u.TargetLang == targetLang {
+			score += 2
+		}
+		if u.SourceLang == sourceLang {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = u, score
+		}
+	}
+	if best == nil {
+		return TMUnit{}, false
+	}
+	return *best, true
+}
+
+// Add 添加或更新条目，相同原文和语言对的条目会被替换
+// 机器译文不会覆盖导入的人工译文
+func (tm *TranslationMemory) Add(unit TMUnit) {
+	unit.Source = strings.TrimSpace(unit.Source)
+	unit.Target = strings.TrimSpace(unit.Target)
+	if unit.Source == "" || unit.Target == "" {
+		return
+	}
+	unit.SourceLang = NormalizeLanguage(unit.SourceLang)
+	unit.TargetLang = NormalizeLanguage(unit.TargetLang)
+	if unit.Origin == "" {
+		unit.Origin = TMOriginMachine
+	}
+	now := time.Now().UTC()
+	if unit.CreatedAt.IsZero() {
+		unit.CreatedAt = now
+	}
+	if unit.UpdatedAt.IsZero() {
+		unit.UpdatedAt = now
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	key := tmKey(unit.Source)
+	for i, existing := range tm.units[key] {
+		if existing.SourceLang != unit.SourceLang || existing.TargetLang != unit.TargetLang {
+			continue
+		}
+		if existing.Origin == TMOriginImport && unit.Origin != TMOriginImport {
+			return
+		}
+		unit.CreatedAt = existing.CreatedAt
+		tm.units[key][i] = &unit
+		tm.dirty = true
+		return
+	}
+	if len(tm.units[key]) == 0 {
+		tm.indexKey(key)
+	}
+	tm.units[key] = append(tm.units[key], &unit)
+	tm.dirty = true
+}
+
+// Units 返回指定语言对的全部条目（语言为空表示不限），按原文排序
+func (tm *TranslationMemory) Units(sourceLang, targetLang string) []TMUnit {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	sourceLang = NormalizeLanguage(sourceLang)
+	targetLang = NormalizeLanguage(targetLang)
+
+	var units []TMUnit
+	for _, list := range tm.units {
+		for _, u := range list {
+			if sourceLang != "" && u.SourceLang != "" && !sameLanguage(u.SourceLang, sourceLang) {
+				continue
+			}
+			if targetLang != "" && !sameLanguage(u.TargetLang, targetLang) {
+				continue
+			}
+			units = append(units, *u)
+		}
+	}
+	sort.Slice(units, func(i, j int) bool {
+		if units[i].Source != units[j].Source {
+			return units[i].Source < units[j].Source
+		}
+		return units[i].TargetLang < units[j].TargetLang
+	})
+	return units
+}
+
+// Save 把修改写回文件，没有修改或不持久化时直接返回（tm 为空时也直接返回）
+func (tm *TranslationMemory) Save() error {
+	if tm == nil {
+		return nil
+	}
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if tm.path == "" || !tm.dirty {
+		return nil
+	}
+
+	var units []*TMUnit
+	for _, list := range tm.units {
+		units = append(units, list...)
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i].CreatedAt.Before(units[j].CreatedAt) })
+
+	data, err := json.MarshalIndent(units, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化翻译记忆库失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(tm.path), 0755); err != nil {
+		return err
+	}
+	tmpPath := tm.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, tm.path); err != nil {
+		return err
+	}
+	tm.dirty = false
+	return nil
+}
+
+// remember 把模型翻译的结果写入翻译记忆库，提供商标识 type/model 拆为提供商和模型
+// 记忆库按纯文本记录，原文和译文中内联元素的占位符都会去掉
+func (dt *DocumentTranslator) remember(source string, result segmentResult, sourceLang, targetLang string) {
+	if dt.Memory == nil {
+		return
+	}
+	provider, model, _ := strings.Cut(result.Provider, "/")
+	dt.Memory.Add(TMUnit{
+		Source:     stripPlaceholders(source),
+		Target:     stripPlaceholders(result.Text),
+		SourceLang: sourceLang,
+		TargetLang: targetLang,
+		Provider:   provider,
+		Model:      model,
+		Origin:     TMOriginMachine,
+	})
+}
+
+// lookupMemory 用去掉占位符的原文在翻译记忆库中查找翻译单元
+// direct 为 true 时 match 是可以直接使用的导入译文精确匹配；否则 match 作为参考译文交给模型：
+// 模糊匹配、模型译文的精确匹配（记忆库不记录当时的术语表、提示词和提供商，修改后应重新翻译），
+// 以及含内联元素的翻译单元的精确匹配（记忆库的译文没有占位符，由模型按原文还原）
+func (dt *DocumentTranslator) lookupMemory(unit, sourceLang, targetLang string) (match TMMatch, direct, ok bool) {
+	plain := stripPlaceholders(unit)
+	if exact, ok := dt.Memory.Lookup(plain, sourceLang, targetLang); ok {
+		return TMMatch{TMUnit: exact, Score: 1}, plain == unit && exact.Origin == TMOriginImport, true
+	}
+	match, ok = dt.Memory.FuzzyLookup(plain, sourceLang, targetLang, dt.Options.FuzzyThreshold)
+	return match, false, ok
+}
+
+// lookupCache 查找第 i 个翻译单元的缓存译文，返回实际使用的提示词对应的缓存键
+// 有参考译文时先按不含参考译文的提示词查找：上次翻译时该单元的模型译文还不在记忆库中，提示词里没有这条参考；
+// 未命中再按含参考译文的提示词查找。返回时参考译文已记入 dt.fuzzyMatches
+func (dt *DocumentTranslator) lookupCache(units []string, i int, targetLanguage, userPrompt string, translations map[int]string, reference *TMMatch) (string, CacheEntry, bool) {
+	unit := units[i]
+	delete(dt.fuzzyMatches, unit)
+	key := dt.cacheKey(unit, targetLanguage, dt.segmentPrompt(units, []int{i}, targetLanguage, userPrompt, translations))
+	entry, ok := dt.Cache.GetEntry(key)
+	if reference == nil {
+		return key, entry, ok
+	}
+	dt.fuzzyMatches[unit] = *reference
+	if ok {
+		return key, entry, true
+	}
+	key = dt.cacheKey(unit, targetLanguage, dt.segmentPrompt(units, []int{i}, targetLanguage, userPrompt, translations))
+	entry, ok = dt.Cache.GetEntry(key)
+	return key, entry, ok
+}
+
+// tmKey 生成条目索引键，原文按空白规范化
+func tmKey(source string) string {
+	return strings.Join(strings.Fields(source), " ")
+}
+
+// languageCodes 常见语言名称到 BCP 47 代码的映射，用于把界面上的目标语言与 TMX 的 xml:lang 对齐
+var languageCodes = map[string]string{
+	"english": "en", "英语": "en", "英文": "en",
+	"chinese": "zh", "中文": "zh", "汉语": "zh",
+	"simplified chinese": "zh-cn", "简体中文": "zh-cn", "chinese (simplified)": "zh-cn",
+	"traditional chinese": "zh-tw", "繁体中文": "zh-tw", "繁體中文": "zh-tw", "chinese (traditional)": "zh-tw",
+	"japanese": "ja", "日语": "ja", "日本語": "ja",
+	"korean": "ko", "韩语": "ko", "한국어": "ko",
+	"french": "fr", "法语": "fr", "français": "fr",
+	"german": "de", "德语": "de", "deutsch": "de",
+	"spanish": "es", "西班牙语": "es", "español": "es",
+	"russian": "ru", "俄语": "ru", "русский": "ru",
+	"italian": "it", "意大利语": "it",
+	"portuguese": "pt", "葡萄牙语": "pt",
+}
+
+// NormalizeLanguage 把语言名称或代码规范化为小写的 BCP 47 代码，如 "Simplified Chinese" -> "zh-cn"，"en_US" -> "en-us"
+func NormalizeLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if code, ok := languageCodes[lang]; ok {
+		return code
+	}
+	return strings.ReplaceAll(lang, "_", "-")
+}
+
+// sameLanguage 判断两个规范化的语言代码是否相同；只有一方带地区时按主语言比较
+func sameLanguage(a, b string) bool {
+	if a == b {
+		return true
+	}
+	pa, ra, _ := strings.Cut(a, "-")
+	pb, rb, _ := strings.Cut(b, "-")
+	return pa == pb && (ra == "" || rb == "")
+}
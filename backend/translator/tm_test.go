@@ -0,0 +1,217 @@
+package translator
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testTMX = `<?xml version="1.0" encoding="UTF-8"?>
+<tmx version="1.4">
+  <header creationtool="test" segtype="paragraph" o-tmf="test" adminlang="en" srclang="en-US" datatype="plaintext"/>
+  <body>
+    <tu creationdate="20240102T030405Z" creationid="alice">
+      <tuv xml:lang="en-US"><seg>Chapter <bpt i="1">&lt;b&gt;</bpt>B<ept i="1">&lt;/b&gt;</ept> text.</seg></tuv>
+      <tuv xml:lang="zh-CN"><seg>第 B 章正文。</seg></tuv>
+    </tu>
+    <tu>
+      <tuv lang="EN-US"><seg>Tom &amp; Jerry</seg></tuv>
+      <tuv lang="ZH-CN"><seg>猫和老鼠</seg></tuv>
+    </tu>
+  </body>
+</tmx>`
+
+func TestImportTMX(t *testing.T) {
+	tm := NewTranslationMemory()
+	count, err := tm.ImportTMX(strings.NewReader(testTMX))
+	if err != nil {
+		t.Fatalf("ImportTMX failed: %v", err)
+	}
+	if count != 2 || tm.Len() != 2 {
+		t.Fatalf("Expected 2 units, got count=%d len=%d", count, tm.Len())
+	}
+
+	unit, ok := tm.Lookup("Chapter B text.", "en", "Simplified Chinese")
+	if !ok || unit.Target != "第 B 章正文。" {
+		t.Fatalf("Expected exact match with inline codes stripped, got %+v ok=%v", unit, ok)
+	}
+	if unit.Origin != TMOriginImport || unit.Provider != "alice" || unit.CreatedAt.Year() != 2024 {
+		t.Errorf("Unexpected unit metadata: %+v", unit)
+	}
+	if _, ok := tm.Lookup("Tom and Jerry", "en-US", "zh-CN"); ok {
+		t.Errorf("Expected no match for different text")
+	}
+	if unit, ok := tm.Lookup("Tom &   Jerry", "en-us", "zh"); !ok || unit.Target != "猫和老鼠" {
+		t.Errorf("Expected whitespace-normalized match, got %+v ok=%v", unit, ok)
+	}
+	if _, ok := tm.Lookup("Tom & Jerry", "en", "ja"); ok {
+		t.Errorf("Expected no match for other target language")
+	}
+}
+
+func TestTranslationMemory_ImportWinsOverMachine(t *testing.T) {
+	tm := NewTranslationMemory()
+	tm.Add(TMUnit{Source: "Hello", Target: "机器", SourceLang: "en", TargetLang: "zh-cn", Origin: TMOriginImport})
+	tm.Add(TMUnit{Source: "Hello", Target: "覆盖", SourceLang: "en", TargetLang: "zh-cn", Origin: TMOriginMachine})
+
+	if unit, _ := tm.Lookup("Hello", "en", "zh-cn"); unit.Target != "机器" {
+		t.Errorf("Expected imported unit to be kept, got %q", unit.Target)
+	}
+}
+
+func TestExportTMX_RoundTrip(t *testing.T) {
+	tm := NewTranslationMemory()
+	tm.Add(TMUnit{Source: "A < B & \"C\"", Target: "甲 < 乙", SourceLang: "en", TargetLang: "zh-cn", Provider: "openai", Model: "gpt-4o"})
+	tm.Add(TMUnit{Source: "Other", Target: "Autre", SourceLang: "en", TargetLang: "fr"})
+
+	var buf bytes.Buffer
+	if err := tm.ExportTMX(&buf, "en", "zh-CN"); err != nil {
+		t.Fatalf("ExportTMX failed: %v", err)
+	}
+	if strings.Contains(buf.String(), "Autre") {
+		t.Errorf("Expected export to be limited to the language pair")
+	}
+
+	imported := NewTranslationMemory()
+	if count, err := imported.ImportTMX(&buf); err != nil || count != 1 {
+		t.Fatalf("Expected 1 unit after round trip, got %d, %v", count, err)
+	}
+	unit, ok := imported.Lookup("A < B & \"C\"", "en", "zh-cn")
+	if !ok || unit.Target != "甲 < 乙" || unit.Provider != "openai" || unit.Model != "gpt-4o" {
+		t.Errorf("Unexpected round-trip unit: %+v ok=%v", unit, ok)
+	}
+}
+
+func TestTranslateEPUB_UsesTranslationMemory(t *testing.T) {
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  testOPF,
+		"OEBPS/text/a.xhtml": testChapter("Chapter A text."),
+		"OEBPS/text/b.xhtml": testChapter("Chapter B text."),
+	})
+
+	memory, err := OpenTranslationMemory(filepath.Join(t.TempDir(), "tm.json"))
+	if err != nil {
+		t.Fatalf("OpenTranslationMemory failed: %v", err)
+	}
+	if _, err := memory.ImportTMX(strings.NewReader(testTMX)); err != nil {
+		t.Fatalf("ImportTMX failed: %v", err)
+	}
+
+	client := &recordingClient{}
+	dt := &DocumentTranslator{Client: client, Cache: newTestCache(t), Memory: memory, Provider: "openai/gpt-4o"}
+	output := filepath.Join(t.TempDir(), "out.epub")
+	if _, err := dt.TranslateEPUB("tm", path, output, "Simplified Chinese", "", "bilingual", nil, nil); err != nil {
+		t.Fatalf("TranslateEPUB failed: %v", err)
+	}
+
+	if _, called := client.prompts["Chapter B text."]; called {
+		t.Errorf("Expected TM exact match not to be sent to the client")
+	}
+	if _, called := client.prompts["Chapter A text."]; !called {
+		t.Errorf("Expected unmatched block to be translated")
+	}
+	if got := dt.SegmentProviders()["Chapter B text."]; got != TMProvider {
+		t.Errorf("Expected provider %q, got %q", TMProvider, got)
+	}
+
+	// 新译文写回翻译记忆库并持久化
+	unit, ok := memory.Lookup("Chapter A text.", "en", "zh-cn")
+	if !ok || unit.Target != pseudoTranslate("Chapter A text.") || unit.Origin != TMOriginMachine || unit.Provider != "openai" || unit.Model != "gpt-4o" {
+		t.Errorf("Expected machine translation in memory, got %+v ok=%v", unit, ok)
+	}
+}
+
+func TestTranslateEPUB_TranslationMemoryWithoutPlaceholders(t *testing.T) {
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  testOPF,
+		"OEBPS/text/a.xhtml": testChapter("Read <em>this</em> chapter first."),
+		"OEBPS/text/b.xhtml": testChapter("Plain text here."),
+	})
+
+	memory, err := OpenTranslationMemory(filepath.Join(t.TempDir(), "tm.json"))
+	if err != nil {
+		t.Fatalf("OpenTranslationMemory failed: %v", err)
+	}
+	dt := &DocumentTranslator{Client: &placeholderClient{}, Cache: newTestCache(t), Memory: memory, Provider: "openai/gpt-4o"}
+	output := filepath.Join(t.TempDir(), "out.epub")
+	if _, err := dt.TranslateEPUB("tm1", path, output, "Chinese", "", "bilingual", nil, nil); err != nil {
+		t.Fatalf("TranslateEPUB failed: %v", err)
+	}
+
+	// 记忆库按纯文本记录，导出的 TMX 中没有占位符
+	unit, ok := memory.Lookup("Read this chapter first.", "en", "zh")
+	if !ok || unit.Target != pseudoTranslate("Read this chapter first.") {
+		t.Fatalf("Expected placeholder-free entry in memory, got %+v ok=%v", unit, ok)
+	}
+	var buf bytes.Buffer
+	if err := memory.ExportTMX(&buf, "", ""); err != nil {
+		t.Fatalf("ExportTMX failed: %v", err)
+	}
+	if strings.Contains(buf.String(), "x1") {
+		t.Errorf("Expected no placeholders in exported TMX, got %s", buf.String())
+	}
+
+	// 记忆库中的模型译文只作为参考：没有缓存时重新翻译，含内联元素的块由模型按参考译文还原标签
+	client := &placeholderClient{}
+	dt = &DocumentTranslator{Client: client, Cache: newTestCache(t), Memory: memory, Provider: "openai/gpt-4o"}
+	if _, err := dt.TranslateEPUB("tm2", path, output, "Chinese", "", "bilingual", nil, nil); err != nil {
+		t.Fatalf("TranslateEPUB failed: %v", err)
+	}
+	if got := dt.SegmentProviders()["Plain text here."]; got == TMProvider {
+		t.Errorf("Expected machine memory entry not to be used directly, got provider %q", got)
+	}
+	if client.calls != 1 || !strings.Contains(client.prompts[0], "<translation>"+unit.Target+"</translation>") {
+		t.Errorf("Expected memory entry as reference for the marked-up block, got %q", client.prompts)
+	}
+}
+
+func TestTranslateEPUB_MachineMemoryFollowsGlossary(t *testing.T) {
+	path := writeTestEPUB(t, map[string]string{
+		"OEBPS/content.opf":  testOPF,
+		"OEBPS/text/a.xhtml": testChapter("Frodo left the Shire at dawn."),
+		"OEBPS/text/b.xhtml": testChapter("Sam followed him."),
+	})
+
+	memory, err := OpenTranslationMemory(filepath.Join(t.TempDir(), "tm.json"))
+	if err != nil {
+		t.Fatalf("OpenTranslationMemory failed: %v", err)
+	}
+	cache := newTestCache(t)
+	translate := func(taskID, target string) *recordingClient {
+		t.Helper()
+		client := &recordingClient{}
+		dt := &DocumentTranslator{
+			Client:   client,
+			Cache:    cache,
+			Memory:   memory,
+			Provider: "openai/gpt-4o",
+			Options:  TranslateOptions{Glossary: &Glossary{Entries: []GlossaryEntry{{Source: "Frodo", Target: target}}}},
+		}
+		output := filepath.Join(t.TempDir(), "out.epub")
+		if _, err := dt.TranslateEPUB(taskID, path, output, "Chinese", "", "bilingual", nil, nil); err != nil {
+			t.Fatalf("TranslateEPUB failed: %v", err)
+		}
+		if got := dt.SegmentProviders()["Frodo left the Shire at dawn."]; got == TMProvider {
+			t.Errorf("%s: expected machine memory entry not to be used directly", taskID)
+		}
+		return client
+	}
+
+	translate("first", "弗罗多")
+
+	// 修改术语表后重新翻译，记忆库中的旧译文只作为参考
+	client := translate("edited", "佛罗多")
+	prompt, called := client.prompts["Frodo left the Shire at dawn."]
+	if !called || !strings.Contains(prompt, "佛罗多") || !strings.Contains(prompt, "<reference") {
+		t.Errorf("Expected a fresh request with the new term and a reference, got %q (called %v)", prompt, called)
+	}
+	if _, called := client.prompts["Sam followed him."]; called {
+		t.Error("Expected the unchanged block to come from the cache")
+	}
+
+	// 设置不变时再次翻译全部命中缓存
+	if client := translate("again", "佛罗多"); len(client.prompts) != 0 {
+		t.Errorf("Expected all blocks cached, got requests %v", client.prompts)
+	}
+}
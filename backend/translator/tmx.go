@@ -0,0 +1,196 @@
+package translator
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// tmxTimeFormat TMX 1.4 的日期格式（UTC）
+const tmxTimeFormat = "20060102T150405Z"
+
+// tmxDocument TMX 1.4 文档（只解析用到的部分）
+type tmxDocument struct {
+	XMLName xml.Name `xml:"tmx"`
+	Header  struct {
+		SrcLang      string `xml:"srclang,attr"`
+		CreationDate string `xml:"creationdate,attr"`
+	} `xml:"header"`
+	Units []tmxUnit `xml:"body>tu"`
+}
+
+type tmxUnit struct {
+	SrcLang      string    `xml:"srclang,attr"`
+	CreationDate string    `xml:"creationdate,attr"`
+	ChangeDate   string    `xml:"changedate,attr"`
+	CreationID   string    `xml:"creationid,attr"`
+	Props        []tmxProp `xml:"prop"`
+	Variants     []tmxTUV  `xml:"tuv"`
+}
+
+type tmxProp struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type tmxTUV struct {
+	// 同时匹配 TMX 1.4 的 xml:lang 和旧版本的 lang
+	Lang string `xml:"lang,attr"`
+	Seg  struct {
+		Inner string `xml:",innerxml"`
+	} `xml:"seg"`
+}
+
+var (
+	// tmxNativeCode bpt/ept/ph/it 中是原格式的代码，不属于文本
+	tmxNativeCode = regexp.MustCompile(`(?s)<(bpt|ept|ph|it)\b[^>]*>.*?</(bpt|ept|ph|it)>`)
+	// tmxInlineTag 其余内联标记（hi、sub 等），保留其中的文本
+	tmxInlineTag = regexp.MustCompile(`<[^>]+>`)
+)
+
+// text 返回去掉内联标记后的纯文本
+func (t tmxTUV) text() string {
+	inner := tmxNativeCode.ReplaceAllString(t.Seg.Inner, "")
+	inner = tmxInlineTag.ReplaceAllString(inner, "")
+	return strings.TrimSpace(html.UnescapeString(inner))
+}
+
+// ImportTMX 从 TMX 文件导入条目，作为人工译文的精确匹配，返回导入的条目数
+// 每个 tu 中源语言的 tuv 与其他每个 tuv 组成一对
+func (tm *TranslationMemory) ImportTMX(r io.Reader) (int, error) {
+	var doc tmxDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return 0, fmt.Errorf("解析 TMX 失败: %w", err)
+	}
+
+	imported := 0
+	for _, tu := range doc.Units {
+		srcLang := tu.SrcLang
+		if srcLang == "" {
+			srcLang = doc.Header.SrcLang
+		}
+		if strings.EqualFold(srcLang, "*all*") {
+			srcLang = ""
+		}
+
+		// 找到源语言的 tuv；未声明源语言时取第一个
+		sourceIdx := -1
+		for i, tuv := range tu.Variants {
+			if srcLang != "" && sameLanguage(NormalizeLanguage(tuv.Lang), NormalizeLanguage(srcLang)) {
+				sourceIdx = i
+				break
+			}
+		}
+		if sourceIdx == -1 {
+			if len(tu.Variants) < 2 {
+				continue
+			}
+			sourceIdx = 0
+		}
+		source := tu.Variants[sourceIdx]
+
+		created := parseTMXTime(tu.CreationDate)
+		if created.IsZero() {
+			created = parseTMXTime(doc.Header.CreationDate)
+		}
+		updated := parseTMXTime(tu.ChangeDate)
+
+		provider, model := tu.CreationID, ""
+		for _, prop := range tu.Props {
+			switch prop.Type {
+			case "x-provider":
+				provider = strings.TrimSpace(prop.Value)
+			case "x-model":
+				model = strings.TrimSpace(prop.Value)
+			}
+		}
+
+		for i, target := range tu.Variants {
+			if i == sourceIdx {
+				continue
+			}
+			unit := TMUnit{
+				Source:     source.text(),
+				Target:     target.text(),
+				SourceLang: source.Lang,
+				TargetLang: target.Lang,
+				Provider:   provider,
+				Model:      model,
+				Origin:     TMOriginImport,
+				CreatedAt:  created,
+				UpdatedAt:  updated,
+			}
+			if unit.Source == "" || unit.Target == "" || unit.TargetLang == "" {
+				continue
+			}
+			tm.Add(unit)
+			imported++
+		}
+	}
+	return imported, nil
+}
+
+// ExportTMX 导出指定语言对的条目为 TMX 1.4（语言为空表示不限）
+func (tm *TranslationMemory) ExportTMX(w io.Writer, sourceLang, targetLang string) error {
+	units := tm.Units(sourceLang, targetLang)
+
+	srcLang := NormalizeLanguage(sourceLang)
+	if srcLang == "" {
+		srcLang = "*all*"
+	}
+
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<tmx version="1.4">` + "\n")
+	fmt.Fprintf(&b, `  <header creationtool="etrans" creationtoolversion="1.0" segtype="paragraph" o-tmf="etrans" adminlang="en" srclang="%s" datatype="plaintext" creationdate="%s"/>`+"\n",
+		escapeXMLAttr(srcLang), time.Now().UTC().Format(tmxTimeFormat))
+	b.WriteString("  <body>\n")
+	for _, u := range units {
+		fmt.Fprintf(&b, `    <tu creationdate="%s" changedate="%s"`, u.CreatedAt.UTC().Format(tmxTimeFormat), u.UpdatedAt.UTC().Format(tmxTimeFormat))
+		if u.SourceLang != "" {
+			fmt.Fprintf(&b, ` srclang="%s"`, escapeXMLAttr(u.SourceLang))
+		}
+		b.WriteString(">\n")
+		fmt.Fprintf(&b, "      <prop type=\"x-origin\">%s</prop>\n", escapeXMLText(u.Origin))
+		if u.Provider != "" {
+			fmt.Fprintf(&b, "      <prop type=\"x-provider\">%s</prop>\n", escapeXMLText(u.Provider))
+		}
+		if u.Model != "" {
+			fmt.Fprintf(&b, "      <prop type=\"x-model\">%s</prop>\n", escapeXMLText(u.Model))
+		}
+		sourceLangAttr := u.SourceLang
+		if sourceLangAttr == "" {
+			sourceLangAttr = "und"
+		}
+		fmt.Fprintf(&b, "      <tuv xml:lang=\"%s\"><seg>%s</seg></tuv>\n", escapeXMLAttr(sourceLangAttr), escapeXMLText(u.Source))
+		fmt.Fprintf(&b, "      <tuv xml:lang=\"%s\"><seg>%s</seg></tuv>\n", escapeXMLAttr(u.TargetLang), escapeXMLText(u.Target))
+		b.WriteString("    </tu>\n")
+	}
+	b.WriteString("  </body>\n</tmx>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// parseTMXTime 解析 TMX 日期，格式错误时返回零值
+func parseTMXTime(s string) time.Time {
+	t, err := time.Parse(tmxTimeFormat, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func escapeXMLText(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func escapeXMLAttr(s string) string {
+	return strings.ReplaceAll(escapeXMLText(s), `"`, "&quot;")
+}
@@ -97,7 +97,7 @@ func parseNAV(content []byte) ([]*TOCItem, error) {
 }
 
 // TranslateTOC 翻译目录
-func TranslateTOC(items []*TOCItem, client any, targetLanguage, userPrompt string, cache *Cache) error {
+func TranslateTOC(items []*TOCItem, client any, targetLanguage, userPrompt, provider string, cache *Cache) error {
 	if len(items) == 0 {
 		return nil
 	}
@@ -107,7 +107,7 @@ func TranslateTOC(items []*TOCItem, client any, targetLanguage, userPrompt strin
 	collectTitles(items, &titles)
 
 	// 批量翻译
-	translated, err := translateTitlesWithCache(titles, client, targetLanguage, userPrompt, cache)
+	translated, err := translateTitlesWithCache(titles, client, targetLanguage, userPrompt, provider, cache)
 	if err != nil {
 		return err
 	}
@@ -140,7 +140,7 @@ func fillTitles(items []*TOCItem, translated []string, index *int) {
 	}
 }
 
-func translateTitlesWithCache(titles []string, client any, targetLanguage, userPrompt string, cache *Cache) ([]string, error) {
+func translateTitlesWithCache(titles []string, client any, targetLanguage, userPrompt, provider string, cache *Cache) ([]string, error) {
 	results := make([]string, len(titles))
 
 	for i, title := range titles {
@@ -151,21 +151,19 @@ func translateTitlesWithCache(titles []string, client any, targetLanguage, userP
 
 		// 检查缓存
 		if cache != nil {
-			cacheKey := CacheKey(title, targetLanguage, userPrompt)
+			cacheKey := CacheKey(title, targetLanguage, userPrompt, provider)
 			if cached, ok := cache.Get(cacheKey); ok {
 				results[i] = cached
 				continue
 			}
 		}
 
-		// 翻译 - 支持新旧两种客户端
+		// 翻译 - 支持任意实现了 TranslatorClientInterface 的客户端
 		var translated string
 		var err error
 
 		switch c := client.(type) {
-		case *TranslatorClient:
-			translated, err = c.Translate(title, targetLanguage, userPrompt)
-		case *LLMClient:
+		case TranslatorClientInterface:
 			translated, err = c.Translate(title, targetLanguage, userPrompt)
 		default:
 			return nil, fmt.Errorf("不支持的客户端类型")
@@ -179,7 +177,7 @@ func translateTitlesWithCache(titles []string, client any, targetLanguage, userP
 
 		// 保存到缓存
 		if cache != nil {
-			cacheKey := CacheKey(title, targetLanguage, userPrompt)
+			cacheKey := CacheKey(title, targetLanguage, userPrompt, provider)
 			cache.Set(cacheKey, translated)
 		}
 	}
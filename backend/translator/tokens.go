@@ -0,0 +1,26 @@
+package translator
+
+import "unicode"
+
+// EstimateTokens 粗略估算文本的 token 数
+// 不依赖具体模型的分词器：CJK 字符大约一个字一个 token，其他文字大约 4 个字符一个 token
+func EstimateTokens(text string) int {
+	cjk := 0
+	other := 0
+	for _, r := range text {
+		if isCJK(r) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
+
+// isCJK 判断字符是否为中日韩文字
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
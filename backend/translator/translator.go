@@ -5,12 +5,52 @@ import (
 	"log"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // DocumentTranslator 文档翻译器
 type DocumentTranslator struct {
 	Client TranslatorClientInterface
 	Cache  *Cache
+	// Memory 翻译记忆库，可为空；TranslateEPUB 会先查找精确匹配，再调用客户端
+	Memory  *TranslationMemory
+	Hooks   TranslateHooks
+	Options TranslateOptions
+	// Provider 提供商（链）标识，参与缓存键
+	Provider string
+	// Reviewer 审校客户端，非空时每段初译后再审校一遍（见 EnableReview）；ReviewProvider 为其标识
+	Reviewer       TranslatorClientInterface
+	ReviewProvider string
+
+	// segmentProviders 最近一次翻译中每个文本块由哪个提供商翻译
+	segmentProviders map[string]string
+	// glossaryViolations 最近一次翻译中未按术语表翻译的文本块
+	glossaryViolations []GlossaryViolation
+	// fuzzyMatches 本次翻译中作为参考译文的模糊匹配（原文 -> 匹配），翻译开始前确定
+	fuzzyMatches map[string]TMMatch
+	// segmentMatches 最近一次翻译中每个文本块的翻译记忆库匹配度（原文 -> 相似度）
+	segmentMatches map[string]float64
+	// qualityFlags 最近一次翻译中重试后仍未通过质量检查的文本块
+	qualityFlags []QualityFlag
+	// book 当前翻译的书籍信息，用于渲染提示词模板
+	book bookInfo
+	// drafts 最近一次翻译中审校过的文本块的初译和审校后的译文
+	drafts map[string]ReviewDraft
+	// blockLanguages 最近一次翻译中每个翻译单元检测到的语言（原文 -> 语言代码，无法判断时为空）
+	blockLanguages map[string]string
+	// sourceLanguage 最近一次翻译的源语言，skippedBlocks 已是目标语言而未翻译的翻译单元数
+	sourceLanguage string
+	skippedBlocks  int
+}
+
+// TranslateHooks 翻译过程中的事件回调，均可为空
+type TranslateHooks struct {
+	// OnRetry 请求因限流或服务端错误即将重试时调用
+	OnRetry func(RetryEvent)
+	// OnQueueWait 请求因客户端限流开始排队时以最晚的剩余等待时间调用，同一提供商所有排队的请求都结束后以 0 调用
+	OnQueueWait func(time.Duration)
+	// OnGlossaryProposal 术语提取完成时以建议术语表（已合并现有术语表）调用
+	OnGlossaryProposal func(*Glossary)
 }
 
 // TranslatorClientInterface 翻译客户端接口
@@ -20,24 +60,162 @@ type TranslatorClientInterface interface {
 
 // NewDocumentTranslator 创建新的文档翻译器
 func NewDocumentTranslator(config ProviderConfig, cache *Cache) (*DocumentTranslator, error) {
-	// 创建对应的翻译客户端
+	dt := &DocumentTranslator{Cache: cache}
+
+	client, label, err := dt.newChainClient(config)
+	if err != nil {
+		return nil, err
+	}
+	dt.Client = client
+	dt.Provider = label
+
+	return dt, nil
+}
+
+// newChainClient 创建提供商的客户端，配置了备用提供商时按顺序组成备用链（每个提供商各自重试和限流）
+// 返回客户端和提供商（链）标识
+func (dt *DocumentTranslator) newChainClient(config ProviderConfig) (TranslatorClientInterface, string, error) {
+	client, err := dt.newProviderClient(config)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(config.Fallbacks) == 0 {
+		return client, config.Label(), nil
+	}
+
+	chain := NewFallbackClient(FallbackProvider{Name: config.Label(), Client: client})
+	labels := []string{config.Label()}
+	for _, fallback := range config.Fallbacks {
+		fallbackClient, err := dt.newProviderClient(fallback)
+		if err != nil {
+			return nil, "", fmt.Errorf("创建备用提供商 %s 失败: %w", fallback.Label(), err)
+		}
+		chain.Providers = append(chain.Providers, FallbackProvider{Name: fallback.Label(), Client: fallbackClient})
+		labels = append(labels, fallback.Label())
+	}
+	chain.Check = checkOutput
+	chain.OnFallback = func(provider string, err error) {
+		log.Printf("提供商 %s 翻译失败，尝试下一个提供商: %v", provider, err)
+	}
+	return chain, strings.Join(labels, ">"), nil
+}
+
+// newProviderClient 创建单个提供商的客户端，并套上限流和重试
+func (dt *DocumentTranslator) newProviderClient(config ProviderConfig) (TranslatorClientInterface, error) {
 	var client TranslatorClientInterface
 
 	// 根据提供商类型创建客户端
-	// 这里简化实现，使用基础的翻译客户端
-	// 实际应该根据 config.Type 创建不同的客户端
 	switch config.Type {
-	case ProviderOpenAI, ProviderClaude, ProviderGemini, ProviderDeepSeek, ProviderOllama, ProviderNLTranslator, ProviderCustom:
-		// 使用通用的LLM客户端
-		client = NewLLMClient(config.APIKey, config.APIURL, config.Model)
+	case ProviderClaude:
+		// Claude 使用原生 Messages API
+		client = NewClaudeClient(config)
+	case ProviderGemini:
+		// Gemini 使用原生 generateContent API
+		client = NewGeminiClient(config)
+	case ProviderOllama:
+		// Ollama 使用原生 /api/chat 流式接口
+		client = NewOllamaClient(config)
+	case ProviderOpenAI, ProviderDeepSeek, ProviderNLTranslator, ProviderCustom:
+		// 使用通用的 OpenAI 兼容客户端
+		client = NewLLMClientWithConfig(config)
 	default:
 		return nil, fmt.Errorf("不支持的翻译提供商: %s", config.Type)
 	}
 
-	return &DocumentTranslator{
-		Client: client,
-		Cache:  cache,
-	}, nil
+	// 配置了限流时，使用同一提供商账号在进程内共享的限流器
+	// 限流放在重试内层，这样每次重试也会占用额度
+	if config.RateLimit.Enabled() {
+		limited := NewRateLimitedClient(client, SharedRateLimiter(RateLimitKey(config), config.RateLimit))
+		limited.OnWait = func(wait time.Duration) {
+			if dt.Hooks.OnQueueWait != nil {
+				dt.Hooks.OnQueueWait(wait)
+			}
+		}
+		client = limited
+	}
+
+	// 所有提供商统一套上重试，回调在运行时读取 dt.Hooks，便于调用方在创建后再设置
+	retryClient := NewRetryClient(client, RetryPolicyFromConfig(config))
+	retryClient.OnRetry = func(e RetryEvent) {
+		log.Printf("请求失败，%s 后进行第 %d 次尝试: %v", e.Delay.Round(time.Millisecond), e.Attempt, e.Err)
+		if dt.Hooks.OnRetry != nil {
+			dt.Hooks.OnRetry(e)
+		}
+	}
+	return retryClient, nil
+}
+
+// translate 调用客户端翻译文本，同时返回产生译文的提供商
+func (dt *DocumentTranslator) translate(text, targetLanguage, userPrompt string) (string, string, error) {
+	return translateAttributed(dt.Client, dt.Provider, text, targetLanguage, userPrompt)
+}
+
+// structuredTranslator 能发送不做逐段质量检查的结构化请求的客户端（备用链）
+type structuredTranslator interface {
+	TranslateStructured(text, targetLanguage, userPrompt string) (translated, provider string, err error)
+}
+
+// translateStructured 发送 etrans 自己构造的结构化请求（批量翻译、术语提取），同时返回产生结果的提供商
+// 结果由调用方解析和校验，备用链不把它当作一段译文检查
+func (dt *DocumentTranslator) translateStructured(payload, targetLanguage, userPrompt string) (string, string, error) {
+	if structured, ok := dt.Client.(structuredTranslator); ok {
+		return structured.TranslateStructured(payload, targetLanguage, userPrompt)
+	}
+	return dt.translate(payload, targetLanguage, userPrompt)
+}
+
+// translateAttributed 调用 client 翻译文本，同时返回产生译文的提供商；client 无法报告时返回 label
+func translateAttributed(client TranslatorClientInterface, label, text, targetLanguage, userPrompt string) (string, string, error) {
+	if attributed, ok := client.(attributedTranslator); ok {
+		return attributed.TranslateAttributed(text, targetLanguage, userPrompt)
+	}
+	translated, err := client.Translate(text, targetLanguage, userPrompt)
+	return translated, label, err
+}
+
+// cacheKey 生成当前翻译器的缓存键，启用审校时缓存的是审校后的译文，键中同时包含审校提供商
+func (dt *DocumentTranslator) cacheKey(text, targetLanguage, userPrompt string) string {
+	provider := dt.Provider
+	if dt.Reviewer != nil {
+		provider += "+review:" + dt.ReviewProvider
+	}
+	return CacheKey(text, targetLanguage, userPrompt, provider)
+}
+
+// GlossaryViolations 返回最近一次翻译中未按术语表翻译的记录
+func (dt *DocumentTranslator) GlossaryViolations() []GlossaryViolation {
+	return dt.glossaryViolations
+}
+
+// QualityFlags 返回最近一次翻译中重试后仍未通过质量检查、按原样保留的译文
+func (dt *DocumentTranslator) QualityFlags() []QualityFlag {
+	return dt.qualityFlags
+}
+
+// SourceLanguage 返回最近一次翻译的源语言：检测到的占比最高的语言，检测不出时为 EPUB 元数据中的语言
+func (dt *DocumentTranslator) SourceLanguage() string {
+	return dt.sourceLanguage
+}
+
+// BlockLanguages 返回最近一次翻译中每个翻译单元（原文）检测到的语言，无法判断的为空
+func (dt *DocumentTranslator) BlockLanguages() map[string]string {
+	return dt.blockLanguages
+}
+
+// SkippedBlocks 返回最近一次翻译中已是目标语言、保留原文的翻译单元数
+func (dt *DocumentTranslator) SkippedBlocks() int {
+	return dt.skippedBlocks
+}
+
+// SegmentMatches 返回最近一次翻译中每个文本块（原文）的翻译记忆库匹配度
+// 精确匹配为 1，模糊匹配为相似度；未匹配的文本块不出现
+func (dt *DocumentTranslator) SegmentMatches() map[string]float64 {
+	return dt.segmentMatches
+}
+
+// SegmentProviders 返回最近一次翻译中每个文本块（原文）由哪个提供商翻译
+func (dt *DocumentTranslator) SegmentProviders() map[string]string {
+	return dt.segmentProviders
 }
 
 // TranslateDocument 翻译文档（统一入口）
@@ -65,87 +243,224 @@ func (dt *DocumentTranslator) TranslateEPUB(taskID, inputPath, outputPath, targe
 		return "", fmt.Errorf("打开EPUB文档失败: %w", err)
 	}
 
-	// 获取文本块
-	textBlocks := doc.GetTextBlocks()
+	// 获取文本块：译文按翻译单元记录，进度和插入按文本块 ID
+	segments := doc.GetSegments()
+	textBlocks := segmentTexts(segments)
 	if len(textBlocks) == 0 {
 		return "", fmt.Errorf("EPUB中没有可翻译的文本内容")
 	}
 
 	log.Printf("找到 %d 个文本块", len(textBlocks))
 
-	// 批量翻译
-	translations := make(map[string]string)
+	// 超过 token 上限的文本块按句子拆分为多个部分分别翻译，之后再拼接；
+	// 检测出的源语言用于匹配翻译记忆库的语言对
+	units, parts := dt.prepareUnits(doc, segments, targetLanguage)
+	sourceLanguage := dt.sourceLanguage
+
+	// 术语提取：生成建议术语表后暂停，等待用户审核修改后再继续翻译
+	if dt.Options.ExtractGlossary {
+		proposal, err := dt.ProposeGlossary(textBlocks, targetLanguage, userPrompt)
+		if err != nil {
+			return "", err
+		}
+		merged := &Glossary{}
+		if dt.Options.Glossary != nil {
+			merged.Entries = append(merged.Entries, dt.Options.Glossary.Entries...)
+		}
+		merged.Merge(proposal)
+		log.Printf("术语提取完成：建议 %d 个术语，等待审核", len(merged.Entries))
+		if dt.Hooks.OnGlossaryProposal != nil {
+			dt.Hooks.OnGlossaryProposal(merged)
+		}
+		return "", ErrGlossaryReview
+	}
+
+	// 批量翻译，译文按翻译单元在 units 中的下标记录
+	translations := make(map[int]string)
 
-	// 尝试加载之前的进度
+	// 尝试加载之前的进度，进度按文本块 ID 保存
+	keys := progressKeys(segments, parts)
 	if savedProgress, err := dt.Cache.LoadProgressMap(taskID); err == nil && savedProgress != nil {
 		log.Printf("加载已保存的进度: %d 个条目", len(savedProgress))
-		translations = savedProgress
-	}
-
-	for i, block := range textBlocks {
-		// 检查任务状态
-		if checkStatus != nil {
-			status := checkStatus()
-			if status == "paused" {
-				// 保存当前进度
-				if err := dt.Cache.SaveProgressMap(taskID, translations); err != nil {
-					log.Printf("保存进度失败: %v", err)
-				}
-				return "", ErrPaused
-			}
-		}
+		translations = restoreProgress(savedProgress, keys)
+	}
+	providers := make(map[string]string)
+	if savedProviders, err := dt.Cache.LoadProviderMap(taskID); err == nil && savedProviders != nil {
+		providers = savedProviders
+	}
+	matches := make(map[string]float64)
+	if savedMatches, err := dt.Cache.LoadMatchMap(taskID); err == nil && savedMatches != nil {
+		matches = savedMatches
+	}
+	drafts, err := dt.Cache.LoadDraftMap(taskID)
+	if err != nil {
+		log.Printf("加载审校记录失败: %v", err)
+	}
+	dt.fuzzyMatches = make(map[string]TMMatch)
 
+	splitPart := make(map[string]bool)
+	for _, blockParts := range parts {
+		for _, part := range blockParts {
+			splitPart[part] = true
+		}
+	}
+	skipped := make(map[string]bool)
+
+	// 收集需要翻译的翻译单元：跳过已有进度和缓存命中的单元
+	// 相同的原文在不同的上下文（章节、前文、术语）中提示词不同，各自翻译；
+	// 原文和提示词都相同的单元只发出一次请求，其余的共用译文
+	var pending []int                 // 待翻译单元在 units 中的下标
+	queued := make(map[string]int)    // 缓存键 -> 待翻译单元的下标
+	duplicates := make(map[int][]int) // 待翻译单元的下标 -> 共用其译文的单元
+	for i, block := range units {
 		if block == "" {
 			continue
 		}
 
-		// 如果已经翻译过（从进度加载或刚刚翻译），跳过
-		if _, ok := translations[block]; ok {
+		// 已是目标语言的文本块保留原文；拆分出的部分记为原文，以便与其他部分拼接
+		if inLanguage(dt.blockLanguages[block], targetLanguage) {
+			skipped[block] = true
+			if splitPart[block] {
+				translations[i] = block
+			}
 			continue
 		}
 
-		// 检查缓存
-		cacheKey := CacheKey(block, targetLanguage, userPrompt)
-		if cached, ok := dt.Cache.Get(cacheKey); ok {
-			translations[block] = cached
+		// 如果已经翻译过（从进度加载），跳过
+		if _, ok := translations[i]; ok {
 			continue
 		}
 
-		// 翻译文本
-		translated, err := dt.Client.Translate(block, targetLanguage, userPrompt)
-		if err != nil {
-			log.Printf("翻译文本失败: %s, 错误: %v", block, err)
-			// 失败时不保存到 translations map，也不写入缓存
-			// 下次重试时会重新翻译
-			continue
+		// 翻译记忆库中导入译文的精确匹配优先于缓存；模型译文和相似度达到阈值的模糊匹配
+		// 作为参考译文交给模型改写，参考译文也是提示词的一部分
+		var reference *TMMatch
+		if match, direct, ok := dt.lookupMemory(block, sourceLanguage, targetLanguage); ok {
+			matches[block] = match.Score
+			if direct {
+				translations[i] = match.Target
+				providers[block] = TMProvider
+				continue
+			}
+			reference = &match
 		}
 
-		translations[block] = translated
+		// 检查缓存（缓存键包含实际使用的上下文）；上下文译文未就绪时提示词未知，单独翻译
+		if dt.contextComplete(units, i, translations) {
+			key, cached, ok := dt.lookupCache(units, i, targetLanguage, userPrompt, translations, reference)
+			if ok {
+				translations[i] = cached.Value
+				providers[block] = cached.Provider
+				continue
+			}
+			if first, ok := queued[key]; ok {
+				duplicates[first] = append(duplicates[first], i)
+				continue
+			}
+			queued[key] = i
+		} else if reference != nil {
+			dt.fuzzyMatches[block] = *reference
+		}
 
-		// 保存到缓存
-		dt.Cache.Set(cacheKey, translated)
+		pending = append(pending, i)
+	}
 
-		// 更新进度
-		if progressCallback != nil {
-			progress := float64(i+1) / float64(len(textBlocks))
-			progressCallback(progress)
+	dt.segmentMatches = matches
+	if len(matches) > 0 {
+		if err := dt.Cache.SaveMatchMap(taskID, matches); err != nil {
+			log.Printf("保存匹配度记录失败: %v", err)
+		}
+	}
+	if len(dt.fuzzyMatches) > 0 {
+		log.Printf("%d 个文本块在翻译记忆库中有模糊匹配，将作为参考译文", len(dt.fuzzyMatches))
+	}
+
+	// 批量模式下按条数和 token 预算分组，否则每组一个文本块
+	pendingTexts := make([]string, len(pending))
+	for i, idx := range pending {
+		pendingTexts[i] = units[idx]
+	}
+	maxSegments, maxTokens := dt.Options.batchLimits()
+	groups := packBatches(pendingTexts, maxSegments, maxTokens)
+	log.Printf("%d 个待翻译文本块分为 %d 个请求，并发数 %d", len(pending), len(groups), dt.Options.concurrency())
+
+	job := &translateJob{
+		taskID:         taskID,
+		sourceLanguage: sourceLanguage,
+		targetLanguage: targetLanguage,
+		userPrompt:     userPrompt,
+		blocks:         units,
+		total:          len(units),
+		done:           len(units) - len(pending),
+		translations:   translations,
+		duplicates:     duplicates,
+		progressKeys:   keys,
+		providers:      providers,
+		drafts:         drafts,
+	}
+	err = dt.translatePending(job, pending, groups, progressCallback, checkStatus)
+	dt.segmentProviders = providers
+	dt.drafts = job.drafts
+	dt.qualityFlags = job.flags
+	if len(job.flags) > 0 {
+		log.Printf("%d 个文本块未通过质量检查，已标记", len(job.flags))
+	}
+	if saveErr := dt.Memory.Save(); saveErr != nil {
+		log.Printf("保存翻译记忆库失败: %v", saveErr)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := dt.Cache.SaveProviderMap(taskID, providers); err != nil {
+		log.Printf("保存提供商记录失败: %v", err)
+	}
+	if len(job.drafts) > 0 {
+		if err := dt.Cache.SaveDraftMap(taskID, job.drafts); err != nil {
+			log.Printf("保存审校记录失败: %v", err)
+		}
+	}
+
+	dt.skippedBlocks = len(skipped)
+	if len(skipped) > 0 {
+		log.Printf("%d 个文本块已是目标语言，未翻译", len(skipped))
+	}
+
+	// 按文本块 ID 整理译文，拼接拆分翻译的长文本块
+	byID := segmentTranslations(segments, parts, translations, skipped)
+
+	// 检查译文是否遵守术语表（包括从进度和缓存恢复的译文），原文和译文都相同的文本块只检查一次
+	dt.glossaryViolations = nil
+	if dt.Options.Glossary != nil {
+		checked := make(map[[2]string]bool)
+		for _, seg := range segments {
+			translated, ok := byID[seg.ID]
+			if !ok || checked[[2]string{seg.Text, translated}] {
+				continue
+			}
+			checked[[2]string{seg.Text, translated}] = true
+			dt.glossaryViolations = append(dt.glossaryViolations, dt.Options.Glossary.Check(seg.Text, translated)...)
+		}
+		if len(dt.glossaryViolations) > 0 {
+			log.Printf("%d 处译文未按术语表翻译", len(dt.glossaryViolations))
 		}
 	}
 
 	// 插入翻译到EPUB
 	if generateMode == "monolingual" {
-		if err := doc.InsertMonolingualTranslation(translations); err != nil {
+		if err := doc.InsertMonolingualTranslation(byID); err != nil {
 			return "", fmt.Errorf("插入单语翻译失败: %w", err)
 		}
 	} else {
-		if err := doc.InsertTranslation(translations); err != nil {
+		if err := doc.InsertTranslation(byID); err != nil {
 			return "", fmt.Errorf("插入双语翻译失败: %w", err)
 		}
 	}
 
+	// 元数据和目录不属于任何章节，使用不含章节的模板渲染结果
+	basePrompt := dt.composePrompt(targetLanguage, userPrompt, promptParts{})
+
 	// 翻译元数据
 	if epub, ok := doc.(*EPUBFile); ok {
-		if err := TranslateMetadata(epub, dt.Client, targetLanguage, userPrompt, dt.Cache); err != nil {
+		if err := TranslateMetadata(epub, dt.Client, targetLanguage, basePrompt, dt.Provider, dt.Cache); err != nil {
 			log.Printf("翻译元数据失败: %v", err)
 		}
 	}
@@ -154,7 +469,7 @@ func (dt *DocumentTranslator) TranslateEPUB(taskID, inputPath, outputPath, targe
 	if epub, ok := doc.(*EPUBFile); ok {
 		tocItems, err := ParseTOC(epub)
 		if err == nil && len(tocItems) > 0 {
-			if err := TranslateTOC(tocItems, dt.Client, targetLanguage, userPrompt, dt.Cache); err != nil {
+			if err := TranslateTOC(tocItems, dt.Client, targetLanguage, basePrompt, dt.Provider, dt.Cache); err != nil {
 				log.Printf("翻译目录失败: %v", err)
 			} else {
 				WriteTOC(epub, tocItems)
@@ -171,44 +486,140 @@ func (dt *DocumentTranslator) TranslateEPUB(taskID, inputPath, outputPath, targe
 	return outputPath, nil
 }
 
+// prepareUnits 把超过 token 上限的文本块按句子拆分为翻译单元，并检测各单元的语言
+// 同时设置提示词模板使用的书籍信息（书名、作者、章节标题）和本次翻译的源语言：
+// 源语言默认取自 EPUB 元数据，检测出文本的语言后以检测结果为准（不计已是目标语言的单元）
+func (dt *DocumentTranslator) prepareUnits(doc Document, segments []Segment, targetLanguage string) (units []string, parts map[string][]string) {
+	var sourceLanguage string
+	dt.book = bookInfo{}
+	if epub, ok := doc.(*EPUBFile); ok {
+		sourceLanguage = epub.Metadata.Language
+		dt.book = bookInfo{Title: epub.Metadata.Title, Author: epub.Metadata.Author}
+	}
+
+	textBlocks := segmentTexts(segments)
+	chapters := make([]string, len(segments))
+	for i, seg := range segments {
+		chapters[i] = seg.Chapter
+	}
+
+	units, parts = splitLongBlocks(textBlocks, dt.Options.maxBlockTokens())
+	dt.book.chapters = unitChapters(textBlocks, chapters, parts)
+	if len(parts) > 0 {
+		log.Printf("%d 个文本块超过 %d tokens，拆分后共 %d 个翻译单元", len(parts), dt.Options.maxBlockTokens(), len(units))
+	}
+
+	languages := make(map[string]string, len(units))
+	foreign := make(map[string]string)
+	for _, unit := range units {
+		if _, ok := languages[unit]; ok {
+			continue
+		}
+		lang := DetectLanguage(stripPlaceholders(unit))
+		languages[unit] = lang
+		if !inLanguage(lang, targetLanguage) {
+			foreign[unit] = lang
+		}
+	}
+	if detected := dominantLanguage(foreign); detected != "" {
+		sourceLanguage = detected
+	}
+	dt.book.SourceLanguage = sourceLanguage
+	dt.blockLanguages = languages
+	dt.sourceLanguage = sourceLanguage
+	return units, parts
+}
+
 // TranslateText 翻译文本
 func (dt *DocumentTranslator) TranslateText(text, targetLanguage, userPrompt string) (string, error) {
 	// 检查缓存
-	cacheKey := CacheKey(text, targetLanguage, userPrompt)
+	userPrompt = dt.glossaryPrompt(targetLanguage, userPrompt, text)
+	cacheKey := dt.cacheKey(text, targetLanguage, userPrompt)
 	if cached, ok := dt.Cache.Get(cacheKey); ok {
 		return cached, nil
 	}
 
 	// 翻译文本
-	translated, err := dt.Client.Translate(text, targetLanguage, userPrompt)
+	result, err := dt.translateSegment(text, targetLanguage, userPrompt)
 	if err != nil {
 		return "", fmt.Errorf("翻译文本失败: %w", err)
 	}
+	if dt.Reviewer != nil {
+		results := map[string]segmentResult{text: result}
+		if _, ok := dt.reviewResults([]string{text}, []string{userPrompt}, targetLanguage, results)[text]; !ok {
+			return result.Text, nil
+		}
+		result = results[text]
+	}
 
 	// 保存到缓存
-	dt.Cache.Set(cacheKey, translated)
+	dt.Cache.SetEntry(cacheKey, result.Text, result.Provider)
 
-	return translated, nil
+	return result.Text, nil
 }
 
 // BatchTranslate 批量翻译
+// 启用批量模式（Options.BatchSize > 1）时，多个文本会打包到一次请求中
 func (dt *DocumentTranslator) BatchTranslate(texts []string, targetLanguage, userPrompt string) ([]string, error) {
 	results := make([]string, len(texts))
 
+	// 先查缓存，剩下的去重后再按批翻译
+	var pending []string
+	queued := make(map[string]bool)
 	for i, text := range texts {
 		if text == "" {
 			results[i] = ""
 			continue
 		}
-
-		translated, err := dt.TranslateText(text, targetLanguage, userPrompt)
-		if err != nil {
-			log.Printf("批量翻译第 %d 个文本失败: %v", i, err)
-			results[i] = text // 保留原文
+		if cached, ok := dt.Cache.Get(dt.cacheKey(text, targetLanguage, dt.glossaryPrompt(targetLanguage, userPrompt, text))); ok {
+			results[i] = cached
 			continue
 		}
+		if !queued[text] {
+			queued[text] = true
+			pending = append(pending, text)
+		}
+	}
+
+	translations := make(map[string]string)
+	maxSegments, maxTokens := dt.Options.batchLimits()
+	for _, group := range packBatches(pending, maxSegments, maxTokens) {
+		groupTexts := make([]string, len(group))
+		for i, idx := range group {
+			groupTexts[i] = pending[idx]
+		}
+		groupPrompt := dt.glossaryPrompt(targetLanguage, userPrompt, groupTexts...)
+		prompts := make([]string, len(groupTexts))
+		for i, text := range groupTexts {
+			prompts[i] = dt.glossaryPrompt(targetLanguage, userPrompt, text)
+		}
+		groupResults := dt.translateGroup(groupTexts, targetLanguage, groupPrompt)
+		var drafts map[string]ReviewDraft
+		if dt.Reviewer != nil {
+			drafts = dt.reviewResults(groupTexts, prompts, targetLanguage, groupResults)
+		}
+		for i, original := range groupTexts {
+			result, ok := groupResults[original]
+			if !ok {
+				continue
+			}
+			translations[original] = result.Text
+			if _, reviewed := drafts[original]; dt.Reviewer == nil || reviewed {
+				dt.Cache.SetEntry(dt.cacheKey(original, targetLanguage, prompts[i]), result.Text, result.Provider)
+			}
+		}
+	}
 
-		results[i] = translated
+	for i, text := range texts {
+		if text == "" || results[i] != "" {
+			continue
+		}
+		if translated, ok := translations[text]; ok {
+			results[i] = translated
+		} else {
+			log.Printf("批量翻译第 %d 个文本失败，保留原文", i)
+			results[i] = text // 保留原文
+		}
 	}
 
 	return results, nil
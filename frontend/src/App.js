@@ -93,7 +93,7 @@ function App() {
     { value: 'claude', label: 'Claude (Anthropic)', defaultUrl: 'https://api.anthropic.com/v1/messages', defaultModel: 'claude-3-5-sonnet-20241022' },
     { value: 'gemini', label: 'Google Gemini', defaultUrl: 'https://generativelanguage.googleapis.com/v1/models/gemini-pro:generateContent', defaultModel: 'gemini-pro' },
     { value: 'deepseek', label: 'DeepSeek', defaultUrl: 'https://api.deepseek.com/v1/chat/completions', defaultModel: 'deepseek-chat' },
-    { value: 'ollama', label: 'Ollama (本地)', defaultUrl: 'http://localhost:11434/api/generate', defaultModel: 'llama2', noApiKey: true },
+    { value: 'ollama', label: 'Ollama (本地)', defaultUrl: 'http://localhost:11434/api/chat', defaultModel: 'llama2', noApiKey: true },
     { value: 'nltranslator', label: 'NLTranslator (Apple 翻译)', defaultUrl: 'http://localhost:8765/translate', defaultModel: '', noApiKey: true, modelOptional: true },
     { value: 'libretranslate', label: 'LibreTranslate', defaultUrl: 'https://libretranslate.com/translate', defaultModel: '', modelOptional: true, apiKeyOptional: true },
     { value: 'custom', label: '自定义 API', defaultUrl: '', defaultModel: '', modelOptional: true },